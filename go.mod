@@ -6,6 +6,7 @@ toolchain go1.24.3
 
 require (
 	github.com/Masterminds/semver/v3 v3.3.1
+	github.com/ProtonMail/go-crypto v1.3.0
 	github.com/deckarep/golang-set/v2 v2.8.0
 	github.com/go-git/go-billy/v5 v5.6.2
 	github.com/go-git/go-git/v5 v5.16.0
@@ -14,12 +15,12 @@ require (
 	github.com/spf13/cobra v1.9.1
 	github.com/swaggest/jsonschema-go v0.3.78
 	gitlab.com/gitlab-org/api/client-go v0.129.0
+	golang.org/x/crypto v0.38.0
 )
 
 require (
 	dario.cat/mergo v1.0.2 // indirect
 	github.com/Microsoft/go-winio v0.6.2 // indirect
-	github.com/ProtonMail/go-crypto v1.3.0 // indirect
 	github.com/cloudflare/circl v1.6.1 // indirect
 	github.com/cyphar/filepath-securejoin v0.4.1 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
@@ -37,7 +38,6 @@ require (
 	github.com/spf13/pflag v1.0.6 // indirect
 	github.com/swaggest/refl v1.4.0 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
-	golang.org/x/crypto v0.38.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
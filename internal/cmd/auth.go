@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/greatliontech/semrel/internal/repository"
+	"golang.org/x/crypto/ssh"
+)
+
+// authFlags are the flags of pushing to the remote
+type authFlags struct {
+	remote                string
+	username              string
+	password              string
+	token                 string
+	sshKey                string
+	sshPassphrase         string
+	knownHosts            string
+	insecureIgnoreHostKey bool
+}
+
+// pushOptions returns the remote and auth to push with. Environment variables
+// take precedence over flags. Basic auth is used if credentials are set, else
+// SSH remotes use the key file if set, or else the ssh agent.
+func (f *authFlags) pushOptions(repo *repository.Repo) (*repository.PushOptions, error) {
+	for env, flag := range map[string]*string{
+		"SEMREL_REMOTE":              &f.remote,
+		"SEMREL_AUTH_USERNAME":       &f.username,
+		"SEMREL_AUTH_PASSWORD":       &f.password,
+		"SEMREL_AUTH_TOKEN":          &f.token,
+		"SEMREL_AUTH_SSH_KEY":        &f.sshKey,
+		"SEMREL_AUTH_SSH_PASSPHRASE": &f.sshPassphrase,
+		"SEMREL_KNOWN_HOSTS":         &f.knownHosts,
+	} {
+		if v := os.Getenv(env); v != "" {
+			*flag = v
+		}
+	}
+	push := &repository.PushOptions{Remote: f.remote}
+
+	if f.token != "" {
+		push.Auth = &http.BasicAuth{
+			Username: "git",
+			Password: f.token,
+		}
+		return push, nil
+	}
+	if f.username != "" && f.password != "" {
+		push.Auth = &http.BasicAuth{
+			Username: f.username,
+			Password: f.password,
+		}
+		return push, nil
+	}
+
+	url, err := repo.RemoteURL(push.Remote)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	if ep.Protocol != "ssh" {
+		return push, nil
+	}
+	user := ep.User
+	if user == "" {
+		user = gitssh.DefaultUsername
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case f.insecureIgnoreHostKey:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	case f.knownHosts != "":
+		hostKeyCallback, err = gitssh.NewKnownHostsCallback(f.knownHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.sshKey != "" {
+		keys, err := gitssh.NewPublicKeysFromFile(user, f.sshKey, f.sshPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		keys.HostKeyCallback = hostKeyCallback
+		push.Auth = keys
+		return push, nil
+	}
+	agent, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, err
+	}
+	agent.HostKeyCallback = hostKeyCallback
+	push.Auth = agent
+	return push, nil
+}
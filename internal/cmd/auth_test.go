@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/greatliontech/semrel/internal/repository"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// remotesRepo returns a repository with the remotes of the given URLs
+func remotesRepo(t *testing.T, remotes map[string]string) *repository.Repo {
+	t.Helper()
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, url := range remotes {
+		if _, err := r.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repository.New(r, "/tmp/test")
+}
+
+// sshKeyFile writes a new private key and returns its path
+func sshKeyFile(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sshAgent serves an empty agent on SSH_AUTH_SOCK until the test ends
+func sshAgent(t *testing.T) {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+}
+
+func TestPushOptions(t *testing.T) {
+	repo := remotesRepo(t, map[string]string{
+		"origin":   "https://github.com/owner/repo.git",
+		"upstream": "ssh://deploy@example.com:2222/owner/repo.git",
+		"scp":      "git@example.com:owner/repo.git",
+	})
+	key := sshKeyFile(t)
+	sshAgent(t)
+
+	tests := []struct {
+		name    string
+		flags   authFlags
+		env     map[string]string
+		remote  string
+		auth    string
+		user    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "https", flags: authFlags{remote: "origin"}, remote: "origin", auth: "none"},
+		{name: "https token", flags: authFlags{remote: "origin", token: "secret"}, remote: "origin", auth: "basic", user: "git"},
+		{name: "https credentials", flags: authFlags{remote: "origin", username: "user", password: "secret"}, remote: "origin", auth: "basic", user: "user"},
+		{name: "env token", flags: authFlags{remote: "origin", token: "flag"}, env: map[string]string{"SEMREL_AUTH_TOKEN": "env"}, remote: "origin", auth: "basic", user: "git", secret: "env"},
+		{name: "env credentials", flags: authFlags{remote: "origin"}, env: map[string]string{"SEMREL_AUTH_USERNAME": "user", "SEMREL_AUTH_PASSWORD": "secret"}, remote: "origin", auth: "basic", user: "user"},
+		{name: "ssh key", flags: authFlags{remote: "upstream", sshKey: key}, remote: "upstream", auth: "key", user: "deploy"},
+		{name: "ssh agent", flags: authFlags{remote: "upstream"}, remote: "upstream", auth: "agent", user: "deploy"},
+		{name: "scp key", flags: authFlags{remote: "scp", sshKey: key}, remote: "scp", auth: "key", user: "git"},
+		{name: "scp agent", flags: authFlags{remote: "scp"}, remote: "scp", auth: "agent", user: "git"},
+		{name: "scp token", flags: authFlags{remote: "scp", token: "secret"}, remote: "scp", auth: "basic", user: "git"},
+		{name: "env remote", flags: authFlags{remote: "origin"}, env: map[string]string{"SEMREL_REMOTE": "scp", "SEMREL_AUTH_SSH_KEY": key}, remote: "scp", auth: "key", user: "git"},
+		{name: "missing remote", flags: authFlags{remote: "fork"}, wantErr: true},
+		{name: "missing key", flags: authFlags{remote: "scp", sshKey: filepath.Join(t.TempDir(), "missing")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{"SEMREL_REMOTE", "SEMREL_AUTH_USERNAME", "SEMREL_AUTH_PASSWORD", "SEMREL_AUTH_TOKEN", "SEMREL_AUTH_SSH_KEY", "SEMREL_AUTH_SSH_PASSPHRASE", "SEMREL_KNOWN_HOSTS"} {
+				t.Setenv(env, tt.env[env])
+			}
+			push, err := tt.flags.pushOptions(repo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pushOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if push.Remote != tt.remote {
+				t.Errorf("expected remote %s, got %s", tt.remote, push.Remote)
+			}
+			switch auth := push.Auth.(type) {
+			case nil:
+				if tt.auth != "none" {
+					t.Errorf("expected %s auth, got none", tt.auth)
+				}
+			case *http.BasicAuth:
+				if tt.auth != "basic" || auth.Username != tt.user {
+					t.Errorf("expected %s auth of %s, got basic auth of %s", tt.auth, tt.user, auth.Username)
+				}
+				if tt.secret != "" && auth.Password != tt.secret {
+					t.Errorf("expected password %s, got %s", tt.secret, auth.Password)
+				}
+			case *gitssh.PublicKeys:
+				if tt.auth != "key" || auth.User != tt.user {
+					t.Errorf("expected %s auth of %s, got key auth of %s", tt.auth, tt.user, auth.User)
+				}
+			case *gitssh.PublicKeysCallback:
+				if tt.auth != "agent" || auth.User != tt.user {
+					t.Errorf("expected %s auth of %s, got agent auth of %s", tt.auth, tt.user, auth.User)
+				}
+			default:
+				t.Errorf("expected %s auth, got %T", tt.auth, auth)
+			}
+		})
+	}
+}
+
+func TestPushOptionsHostKey(t *testing.T) {
+	repo := remotesRepo(t, map[string]string{"origin": "git@example.com:owner/repo.git"})
+	key := sshKeyFile(t)
+
+	push, err := (&authFlags{remote: "origin", sshKey: key, insecureIgnoreHostKey: true}).pushOptions(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if push.Auth.(*gitssh.PublicKeys).HostKeyCallback == nil {
+		t.Error("expected a host key callback ignoring the host key")
+	}
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(knownHosts, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SEMREL_KNOWN_HOSTS", knownHosts)
+	push, err = (&authFlags{remote: "origin", sshKey: key}).pushOptions(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if push.Auth.(*gitssh.PublicKeys).HostKeyCallback == nil {
+		t.Error("expected a host key callback of the known hosts")
+	}
+
+	t.Setenv("SEMREL_KNOWN_HOSTS", filepath.Join(t.TempDir(), "missing"))
+	if _, err := (&authFlags{remote: "origin", sshKey: key}).pushOptions(repo); err == nil {
+		t.Error("expected error for a missing known hosts file")
+	}
+}
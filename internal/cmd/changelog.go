@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/greatliontech/semrel/internal/release"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+	"github.com/spf13/cobra"
+)
+
+const defaultChangelogPath = "CHANGELOG.md"
+
+type changelogCommand struct {
+	cmd               *cobra.Command
+	repo              *repository.Repo
+	cfg               *semrel.Config
+	currentBranchOnly bool
+	pkg               string
+	regenerate        bool
+	// remote is the persistent remote flag of the root command
+	remote *string
+}
+
+func newChangelogCommand(repo *repository.Repo, cfg *semrel.Config, remote *string) *changelogCommand {
+	c := &changelogCommand{
+		repo:   repo,
+		cfg:    cfg,
+		remote: remote,
+	}
+	cmd := &cobra.Command{
+		Use:         "changelog",
+		Short:       "Add the next version to the changelog file",
+		RunE:        c.runE,
+		Annotations: textOnly,
+	}
+	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only tags from the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "only the given package")
+	cmd.Flags().BoolVarP(&c.regenerate, "regenerate", "", false, "regenerate the whole changelog from the tag history")
+	c.cmd = cmd
+	return c
+}
+
+func (c *changelogCommand) runE(cmd *cobra.Command, args []string) error {
+	conf := c.cfg.Changelog()
+	if conf == nil {
+		conf = &semrel.Changelog{}
+	}
+	style, err := release.NewChangelogStyle(conf.Style)
+	if err != nil {
+		return err
+	}
+	links, err := notesLinks(c.repo, c.cfg, *c.remote)
+	if err != nil {
+		return err
+	}
+	// the changelog compare URL has precedence
+	if conf.CompareURL != "" {
+		compare, err := release.NewLinks("", conf.CompareURL)
+		if err != nil {
+			return err
+		}
+		if links == nil {
+			links = compare
+		}
+		links.Compare = compare.Compare
+	}
+	name := conf.Path
+	if name == "" {
+		name = defaultChangelogPath
+	}
+
+	pkgs, err := selectPackages(c.cfg, c.pkg)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		path := filepath.Join(c.repo.Root(), pkg.Path, name)
+		var changelog string
+		if c.regenerate {
+			changelog, err = c.regenerateChangelog(pkg, style, links)
+		} else {
+			changelog, err = c.prependChangelog(pkg, path, style, links)
+		}
+		if err != nil {
+			return err
+		}
+		if changelog == "" {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(changelog), 0o644); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// prependChangelog adds the next version of pkg to the changelog at path. It
+// returns an empty string if there is no new version.
+func (c *changelogCommand) prependChangelog(pkg semrel.Package, path string, style release.ChangelogStyle, links *release.Links) (string, error) {
+	// the changelog lists final versions only
+	plan, err := newPipeline(c.repo, c.cfg, c.currentBranchOnly).Plan(pkg, nil)
+	if err != nil {
+		return "", err
+	}
+	if !plan.Needed() {
+		return "", nil
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	previous := ""
+	if plan.Released != nil {
+		previous = plan.Released.Tag
+	}
+	// the entry links the comparison, the notes only the commits
+	notes, err := releaseNotes(c.repo.Root(), c.cfg, plan.Commits, &release.NotesOptions{Links: links})
+	if err != nil {
+		return "", err
+	}
+	entry := &release.ChangelogEntry{
+		Version:    plan.Next.String(),
+		Date:       time.Now().UTC(),
+		Notes:      notes,
+		CompareURL: links.CompareURL(previous, plan.NextTag),
+	}
+	return release.PrependChangelog(string(existing), style, entry), nil
+}
+
+// regenerateChangelog renders the changelog of all tagged versions of pkg
+func (c *changelogCommand) regenerateChangelog(pkg semrel.Package, style release.ChangelogStyle, links *release.Links) (string, error) {
+	format, err := c.cfg.TagFormat(pkg)
+	if err != nil {
+		return "", err
+	}
+	all, err := c.repo.Versions(format, c.currentBranchOnly)
+	if err != nil {
+		return "", err
+	}
+	versions := []repository.VersionReference{}
+	for _, v := range all {
+		if c.cfg.IncludePrereleases() || v.Version.Prerelease() == "" {
+			versions = append(versions, v)
+		}
+	}
+	entries := []*release.ChangelogEntry{}
+	for i, v := range versions {
+		to := plumbing.ZeroHash
+		previous := ""
+		if i+1 < len(versions) {
+			to = versions[i+1].Commit
+			previous = versions[i+1].Ref.Name().Short()
+		}
+		commits, err := c.repo.Commits(v.Commit, to, logOptions(c.cfg, pkg))
+		if err != nil {
+			return "", err
+		}
+		notes, err := releaseNotes(c.repo.Root(), c.cfg, commits, &release.NotesOptions{Links: links})
+		if err != nil {
+			return "", err
+		}
+		date, err := c.repo.CommitTime(v.Commit)
+		if err != nil {
+			return "", err
+		}
+		entry := &release.ChangelogEntry{
+			Version:    v.Version.String(),
+			Date:       date.UTC(),
+			Notes:      notes,
+			CompareURL: links.CompareURL(previous, v.Ref.Name().Short()),
+		}
+		entries = append(entries, entry)
+	}
+	return release.GenerateChangelog(style, entries), nil
+}
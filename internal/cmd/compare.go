@@ -5,34 +5,40 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
 	"github.com/spf13/cobra"
 )
 
 type compareCommand struct {
 	cmd               *cobra.Command
 	repo              *repository.Repo
+	cfg               *semrel.Config
 	le                []string
 	ge                []string
 	lt                []string
 	gt                []string
 	currentBranchOnly bool
+	pkg               string
 }
 
-func newCompareCommand(repo *repository.Repo) *compareCommand {
+func newCompareCommand(repo *repository.Repo, cfg *semrel.Config) *compareCommand {
 	c := &compareCommand{
 		repo: repo,
+		cfg:  cfg,
 	}
 	cmd := &cobra.Command{
-		Use:   "compare",
-		Short: "Compare the current or supplied version with the given versions",
-		RunE:  c.runE,
-		Args:  cobra.MaximumNArgs(1),
+		Use:         "compare",
+		Short:       "Compare the current or supplied version with the given versions",
+		RunE:        c.runE,
+		Annotations: textOnly,
+		Args:        cobra.MaximumNArgs(1),
 	}
 	cmd.Flags().StringSliceVarP(&c.le, "le", "", nil, "less than or equal to")
 	cmd.Flags().StringSliceVarP(&c.ge, "ge", "", nil, "greater than or equal to")
 	cmd.Flags().StringSliceVarP(&c.lt, "lt", "", nil, "less than")
 	cmd.Flags().StringSliceVarP(&c.gt, "gt", "", nil, "greater than")
 	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only compare the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "compare the version of the given package")
 	c.cmd = cmd
 	return c
 }
@@ -43,7 +49,7 @@ func (c *compareCommand) runE(cmd *cobra.Command, args []string) error {
 	var current *semver.Version
 	var err error
 	if len(args) == 0 {
-		current, _, err = c.repo.CurrentVersion(c.currentBranchOnly)
+		current, err = c.currentVersion()
 	} else {
 		current, err = semver.NewVersion(args[0])
 	}
@@ -93,3 +99,20 @@ func (c *compareCommand) runE(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func (c *compareCommand) currentVersion() (*semver.Version, error) {
+	pkg := rootPackage(c.cfg)
+	if c.pkg != "" {
+		var err error
+		pkg, err = c.cfg.Package(c.pkg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	format, err := c.cfg.TagFormat(pkg)
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := c.repo.CurrentVersion(format, c.currentBranchOnly, c.cfg.IncludePrereleases())
+	return current, err
+}
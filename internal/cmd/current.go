@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/greatliontech/semrel/internal/repository"
 	"github.com/greatliontech/semrel/pkg/semrel"
 	"github.com/spf13/cobra"
@@ -13,12 +11,15 @@ type currentCommand struct {
 	repo              *repository.Repo
 	cfg               *semrel.Config
 	currentBranchOnly bool
+	pkg               string
+	out               *output
 }
 
-func newCurrentCommand(repo *repository.Repo, cfg *semrel.Config) *currentCommand {
+func newCurrentCommand(repo *repository.Repo, cfg *semrel.Config, out *output) *currentCommand {
 	c := &currentCommand{
 		repo: repo,
 		cfg:  cfg,
+		out:  out,
 	}
 	cmd := &cobra.Command{
 		Use:   "current",
@@ -26,16 +27,40 @@ func newCurrentCommand(repo *repository.Repo, cfg *semrel.Config) *currentComman
 		RunE:  c.runE,
 	}
 	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only tags from the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "only the given package")
 	c.cmd = cmd
 	return c
 }
 
 func (c *currentCommand) runE(cmd *cobra.Command, args []string) error {
-	cv, _, err := c.repo.CurrentVersion(c.currentBranchOnly)
+	pkgs, err := selectPackages(c.cfg, c.pkg)
 	if err != nil {
 		return err
 	}
-	currentTag := fmt.Sprintf("%s%s", c.cfg.Prefix(), cv.String())
-	fmt.Println(currentTag)
-	return nil
+	for _, pkg := range pkgs {
+		format, err := c.cfg.TagFormat(pkg)
+		if err != nil {
+			return err
+		}
+		cv, ref, err := c.repo.CurrentVersion(format, c.currentBranchOnly, c.cfg.IncludePrereleases())
+		if err != nil {
+			return err
+		}
+		defaultTag, err := format.Tag(cv)
+		if err != nil {
+			return err
+		}
+		// the tag found, which may differ from the configured format
+		currentTag := ""
+		if ref != nil {
+			currentTag = ref.Ref.Name().Short()
+		}
+		c.out.add(&result{
+			Package:        pkg.Name,
+			CurrentVersion: cv.String(),
+			CurrentTag:     currentTag,
+			defaultTag:     defaultTag,
+		})
+	}
+	return c.out.flush()
 }
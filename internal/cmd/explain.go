@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+	"github.com/spf13/cobra"
+)
+
+type explainCommand struct {
+	cmd               *cobra.Command
+	repo              *repository.Repo
+	cfg               *semrel.Config
+	currentBranchOnly bool
+	pkg               string
+}
+
+func newExplainCommand(repo *repository.Repo, cfg *semrel.Config) *explainCommand {
+	c := &explainCommand{
+		repo: repo,
+		cfg:  cfg,
+	}
+	cmd := &cobra.Command{
+		Use:         "explain",
+		Short:       "Explain how the next version is computed",
+		RunE:        c.runE,
+		Annotations: textOnly,
+	}
+	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only tags from the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "only the given package")
+	c.cmd = cmd
+	return c
+}
+
+func (c *explainCommand) runE(cmd *cobra.Command, args []string) error {
+	pkgs, err := selectPackages(c.cfg, c.pkg)
+	if err != nil {
+		return err
+	}
+	branch, err := releaseBranch(c.repo, c.cfg, "", false)
+	if err != nil {
+		return err
+	}
+	p := newPipeline(c.repo, c.cfg, c.currentBranchOnly)
+	for i, pkg := range pkgs {
+		plan, err := p.Plan(pkg, &semrel.PlanOptions{Branch: branch})
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := explainVersion(os.Stdout, c.cfg, plan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explainVersion writes how the next version of plan was computed
+func explainVersion(w io.Writer, cfg *semrel.Config, plan *semrel.Plan) error {
+	if plan.Package.Name != "" {
+		fmt.Fprintf(w, "package: %s (%s)\n", plan.Package.Name, plan.Package.Path)
+	}
+
+	if plan.Decision == nil {
+		if plan.Released == nil {
+			fmt.Fprintln(w, "current: none, no version tag found")
+		} else {
+			fmt.Fprintf(w, "current: %s, an empty version\n", plan.Released.Tag)
+		}
+		explainChannel(w, plan)
+		fmt.Fprintf(w, "next: %s, the initial version\n", plan.NextTag)
+		return nil
+	}
+
+	current := plan.Released.Tag
+	fmt.Fprintf(w, "current: %s at %s\n", current, shortHash(plan.Released.Commit))
+
+	considered := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	skipped := []semrel.Change{}
+	reverted := []semrel.Change{}
+	fmt.Fprintf(w, "commits since %s:", current)
+	if len(plan.Commits) == 0 {
+		fmt.Fprint(w, " none")
+	}
+	fmt.Fprintln(w)
+	for _, e := range plan.Changes {
+		if e.Reverted {
+			reverted = append(reverted, e)
+			continue
+		}
+		if e.Commit == nil {
+			skipped = append(skipped, e)
+			continue
+		}
+		fmt.Fprintf(considered, "  %s\t%s\t%s\t%s\n", shortHash(e.Hash), e.Commit.BumpKind(cfg), commitHeader(e.Commit), e.Commit.Description)
+	}
+	if err := considered.Flush(); err != nil {
+		return err
+	}
+	if len(reverted) > 0 {
+		fmt.Fprintln(w, "skipped, reverted in range:")
+		for _, e := range reverted {
+			fmt.Fprintf(w, "  %s  %s\n", shortHash(e.Hash), e.Subject)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintln(w, "skipped, not parsable commits:")
+		for _, e := range skipped {
+			fmt.Fprintf(w, "  %s  %s\n", shortHash(e.Hash), e.Subject)
+		}
+	}
+
+	d := plan.Decision
+	switch {
+	case d.Default:
+		fmt.Fprintf(w, "bump: %s, the default bump as no commit bumped\n", d.Applied)
+	case d.Bump == semrel.BumpNone:
+		fmt.Fprintln(w, "bump: none, no commit bumped")
+	default:
+		fmt.Fprintf(w, "bump: %s, the highest of the commits\n", d.Bump)
+	}
+	if d.Development {
+		fmt.Fprintf(w, "development: %s bump downgraded to %s as major version 0 is in development\n", semrel.BumpMajor, d.Applied)
+	}
+	explainChannel(w, plan)
+	fmt.Fprintf(w, "next: %s\n", plan.NextTag)
+	return nil
+}
+
+// explainChannel writes how the next version was numbered in its channel
+func explainChannel(w io.Writer, plan *semrel.Plan) {
+	switch {
+	case plan.Channel == "":
+	case plan.Prerelease == nil:
+		fmt.Fprintf(w, "prerelease: first in channel %s\n", plan.Channel)
+	case plan.Next.Equal(plan.Prerelease.Version):
+		fmt.Fprintf(w, "prerelease: %s has no commits since, nothing to release\n", plan.Prerelease.Tag)
+	default:
+		fmt.Fprintf(w, "prerelease: next in channel %s after %s\n", plan.Channel, plan.Prerelease.Tag)
+	}
+}
+
+// commitHeader renders the type, scope and breaking marker of c
+func commitHeader(c *semrel.Commit) string {
+	h := c.Type
+	if c.Scope != "" {
+		h += "(" + c.Scope + ")"
+	}
+	if c.Attention {
+		h += "!"
+	}
+	return h
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/greatliontech/semrel/internal/release"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// bumpFiles sets the next version in the files of pkg and commits them if
+// configured. It reports whether a commit was made.
+func bumpFiles(repo *repository.Repo, cfg *semrel.Config, pkg semrel.Package, next *semver.Version, tag string) (bool, error) {
+	files := cfg.Files(pkg.Name)
+	if len(files) == 0 {
+		return false, nil
+	}
+	paths := []string{}
+	for _, f := range files {
+		var pattern *regexp.Regexp
+		if f.Pattern != "" {
+			var err error
+			pattern, err = regexp.Compile(f.Pattern)
+			if err != nil {
+				return false, err
+			}
+		}
+		err := release.BumpFile(filepath.Join(repo.Root(), f.Path), f.Key, pattern, next.String())
+		if err != nil {
+			return false, err
+		}
+		paths = append(paths, f.Path)
+	}
+	if !cfg.CommitFiles() {
+		return false, nil
+	}
+	msg, err := cfg.CommitMessage(semrel.TagData{Prefix: pkg.Prefix, Package: pkg.Name, Version: next.String()}, tag)
+	if err != nil {
+		return false, err
+	}
+	hash, err := repo.CommitFiles(paths, msg)
+	if err != nil {
+		return false, err
+	}
+	return !hash.IsZero(), nil
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+	"github.com/spf13/cobra"
+)
+
+var errLintFailed = errors.New("lint failed")
+
+// scissors marks the end of the message in commit message files of
+// "git commit --verbose"
+const scissors = "# ------------------------ >8 ------------------------"
+
+type lintCommand struct {
+	cmd         *cobra.Command
+	repo        *repository.Repo
+	cfg         *semrel.Config
+	messageFile string
+}
+
+func newLintCommand(repo *repository.Repo, cfg *semrel.Config) *lintCommand {
+	c := &lintCommand{
+		repo: repo,
+		cfg:  cfg,
+	}
+	cmd := &cobra.Command{
+		Use:   "lint [<range>]",
+		Short: "Lint commit messages",
+		Long: `Lint the commit messages of a range, e.g. "main..HEAD", or since a
+revision, e.g. "main". Without a range, the commits since the current version
+are linted. With --message-file, e.g. in a commit-msg hook, the message of the
+file, or of stdin for "-", is linted.`,
+		Args:        cobra.MaximumNArgs(1),
+		RunE:        c.runE,
+		Annotations: textOnly,
+	}
+	cmd.Flags().StringVarP(&c.messageFile, "message-file", "", "", "lint the message in the file, - for stdin")
+	c.cmd = cmd
+	return c
+}
+
+func (c *lintCommand) runE(cmd *cobra.Command, args []string) error {
+	if c.messageFile != "" {
+		if len(args) > 0 {
+			return errors.New("a range and --message-file are mutually exclusive")
+		}
+		return c.lintMessageFile()
+	}
+
+	from, to, err := c.resolveRange(args)
+	if err != nil {
+		return err
+	}
+	entries, err := c.repo.Log(from, to, &repository.LogOptions{Parser: c.cfg.CommitParser()})
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, e := range entries {
+		if ignoreLint(e.Message) {
+			continue
+		}
+		problems := semrel.LintCommitMessage(e.Message, c.cfg)
+		if len(problems) == 0 {
+			continue
+		}
+		failed = true
+		fmt.Printf("%s %s\n", shortHash(e.Hash.String()), e.Subject)
+		for _, p := range problems {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+	if failed {
+		return errLintFailed
+	}
+	return nil
+}
+
+// resolveRange returns the commits of a "from..to" range, or of "from..HEAD"
+// for a single revision, or since the current version without a range
+func (c *lintCommand) resolveRange(args []string) (plumbing.Hash, plumbing.Hash, error) {
+	if len(args) == 0 {
+		format, err := c.cfg.TagFormat(rootPackage(c.cfg))
+		if err != nil {
+			return plumbing.ZeroHash, plumbing.ZeroHash, err
+		}
+		_, ref, err := c.repo.CurrentVersion(format, false, false)
+		if err != nil || ref == nil {
+			return plumbing.ZeroHash, plumbing.ZeroHash, err
+		}
+		return plumbing.ZeroHash, ref.Commit, nil
+	}
+	fromRev, toRev, err := parseRange(args[0])
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, err
+	}
+	to, err := c.repo.ResolveRevision(fromRev)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, err
+	}
+	from, err := c.repo.ResolveRevision(toRev)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, err
+	}
+	return from, to, nil
+}
+
+// parseRange splits a "from..to" range into its revisions, to is HEAD if
+// omitted. Symmetric "from...to" ranges are not supported.
+func parseRange(arg string) (string, string, error) {
+	if strings.Contains(arg, "...") {
+		return "", "", fmt.Errorf("invalid range %q: symmetric ranges are not supported, use from..to", arg)
+	}
+	fromRev, toRev, _ := strings.Cut(arg, "..")
+	if fromRev == "" {
+		return "", "", fmt.Errorf("invalid range %q: the from revision is missing, use from..to", arg)
+	}
+	if toRev == "" {
+		toRev = "HEAD"
+	}
+	return fromRev, toRev, nil
+}
+
+func (c *lintCommand) lintMessageFile() error {
+	var b []byte
+	var err error
+	if c.messageFile == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(c.messageFile)
+	}
+	if err != nil {
+		return err
+	}
+	message := cleanMessage(string(b))
+	if ignoreLint(message) {
+		return nil
+	}
+	problems := semrel.LintCommitMessage(message, c.cfg)
+	if len(problems) == 0 {
+		return nil
+	}
+	header, _, _ := strings.Cut(message, "\n")
+	fmt.Fprintln(os.Stderr, header)
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "  - %s\n", p)
+	}
+	return errLintFailed
+}
+
+// cleanMessage strips the comments and the diff of a commit message file, as
+// git does with the default cleanup mode
+func cleanMessage(message string) string {
+	message, _, _ = strings.Cut(message, scissors)
+	lines := []string{}
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// ignoreLint reports whether message is generated by git, e.g. merges,
+// fixups and reverts, and not linted
+func ignoreLint(message string) bool {
+	for _, prefix := range []string{"Merge ", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	_, revert := semrel.RevertedHash(message)
+	return revert
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		arg     string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{arg: "v1.0.0..main", from: "v1.0.0", to: "main"},
+		{arg: "v1.0.0..", from: "v1.0.0", to: "HEAD"},
+		{arg: "v1.0.0", from: "v1.0.0", to: "HEAD"},
+		{arg: "v1.0.0...main", wantErr: true},
+		{arg: "..HEAD", wantErr: true},
+		{arg: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseRange(tt.arg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", tt.arg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.arg, err)
+			continue
+		}
+		if from != tt.from || to != tt.to {
+			t.Errorf("%s: expected %s and %s, got %s and %s", tt.arg, tt.from, tt.to, from, to)
+		}
+	}
+}
+
+func TestIgnoreLint(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"Merge branch 'main' into feature\n", true},
+		{"fixup! feat: a feature\n", true},
+		{"Revert \"feat: a feature\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567.\n", true},
+		{"Revert \"feat: a feature\"\n", false},
+		{"feat: a feature\n", false},
+		{"a feature\n", false},
+	}
+	for _, tt := range tests {
+		if got := ignoreLint(tt.message); got != tt.want {
+			t.Errorf("ignoreLint(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
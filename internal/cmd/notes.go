@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/greatliontech/semrel/internal/release"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// releaseNotes generates the notes of commits with the configured filters and
+// match rules, grouped in sections if notes are configured. Template paths are
+// relative to root.
+func releaseNotes(root string, cfg *semrel.Config, commits []*semrel.Commit, opts *release.NotesOptions) (string, error) {
+	var filters *release.Filters
+	if cfg.Filters() != nil {
+		filters = &release.Filters{}
+		filters.Types = cfg.Filters().Types
+		filters.Scopes = cfg.Filters().Scopes
+	}
+
+	rules := []*release.MatchRule{}
+	if len(cfg.MatchRules()) > 0 {
+		for _, rule := range cfg.MatchRules() {
+			r, err := release.NewMatchRule(rule.Match, rule.Replace)
+			if err != nil {
+				return "", fmt.Errorf("invalid match rule: %w", err)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	notes := cfg.Notes()
+	if notes == nil {
+		return release.GenerateReleaseNotes(commits, filters, rules, opts), nil
+	}
+
+	sections := []release.Section{}
+	for _, s := range notes.Sections {
+		sections = append(sections, release.Section{Title: s.Title, Types: s.Types})
+	}
+
+	var tmpl *template.Template
+	if notes.Template != "" {
+		path := filepath.Join(root, notes.Template)
+		var err error
+		tmpl, err = template.New(filepath.Base(path)).ParseFiles(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid notes template: %w", err)
+		}
+	}
+
+	return release.GenerateGroupedReleaseNotes(commits, filters, rules, sections, tmpl, opts)
+}
+
+// notesLinks returns the URL templates of the platform hosting remote, which
+// SEMREL_REMOTE overrides, replaced by the configured ones. It is nil if
+// neither is known.
+func notesLinks(repo *repository.Repo, cfg *semrel.Config, remote string) (*release.Links, error) {
+	if r := os.Getenv("SEMREL_REMOTE"); r != "" {
+		remote = r
+	}
+	var links *release.Links
+	if url, err := repo.RemoteURL(remote); err == nil {
+		if web, err := release.WebURL(url); err == nil {
+			links = release.PlatformLinks(linksPlatform(cfg), web)
+		}
+	}
+	conf := cfg.Links()
+	if conf == nil {
+		return links, nil
+	}
+	custom, err := release.NewLinks(conf.CommitURL, conf.CompareURL)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		return custom, nil
+	}
+	if custom.Commit != nil {
+		links.Commit = custom.Commit
+	}
+	if custom.Compare != nil {
+		links.Compare = custom.Compare
+	}
+	return links, nil
+}
+
+// linksPlatform returns the detected or configured platform, empty if unknown
+func linksPlatform(cfg *semrel.Config) string {
+	if platform, _, _, _, err := release.DetectPlatform(); err == nil {
+		return platform
+	}
+	if p := os.Getenv("SEMREL_PLATFORM"); p != "" {
+		return p
+	}
+	return cfg.Platform()
+}
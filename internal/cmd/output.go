@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText         = "text"
+	outputJSON         = "json"
+	outputEnv          = "env"
+	outputGithubOutput = "github-output"
+)
+
+// textOnly annotates the commands that only write text, which reject the
+// other output formats
+var textOnly = map[string]string{"semrel/output": outputText}
+
+// output collects the results of a command and writes them in the selected
+// format. Text is written as results are added, the other formats on flush.
+type output struct {
+	format  string
+	results []*result
+	// stdout is where results are written, os.Stdout if nil
+	stdout io.Writer
+}
+
+func (o *output) writer() io.Writer {
+	if o.stdout == nil {
+		return os.Stdout
+	}
+	return o.stdout
+}
+
+func (o *output) validate(cmd *cobra.Command) error {
+	switch o.format {
+	case outputText, outputJSON, outputEnv, outputGithubOutput:
+	default:
+		return fmt.Errorf("invalid output format %q, expected one of %s, %s, %s, %s", o.format, outputText, outputJSON, outputEnv, outputGithubOutput)
+	}
+	if o.format != outputText && cmd.Annotations["semrel/output"] == outputText {
+		return fmt.Errorf("%s only supports the %s output format", cmd.CommandPath(), outputText)
+	}
+	return nil
+}
+
+// isText reports whether human readable output can be written to stdout
+func (o *output) isText() bool {
+	return o.format == outputText
+}
+
+// explainWriter is where explanations go, so that they do not mix with
+// machine readable output
+func (o *output) explainWriter() io.Writer {
+	if o.isText() {
+		return o.writer()
+	}
+	return os.Stderr
+}
+
+func (o *output) add(r *result) {
+	if o.isText() {
+		fmt.Fprintln(o.writer(), r.tag())
+		return
+	}
+	o.results = append(o.results, r)
+}
+
+// flush writes the collected results. A single result without a package is
+// written as is, anything else as a list keyed by package.
+func (o *output) flush() error {
+	switch o.format {
+	case outputJSON:
+		enc := json.NewEncoder(o.writer())
+		enc.SetIndent("", "  ")
+		if len(o.results) == 1 && o.results[0].Package == "" {
+			return enc.Encode(o.results[0])
+		}
+		return enc.Encode(o.results)
+	case outputEnv:
+		for _, r := range o.results {
+			for _, f := range r.fields() {
+				key := strings.ToUpper(strings.ReplaceAll(f.key, "-", "_"))
+				if r.Package != "" {
+					key = strings.ToUpper(envName(r.Package)) + "_" + key
+				}
+				if _, err := fmt.Fprintf(o.writer(), "SEMREL_%s=%s\n", key, shellQuote(f.value)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case outputGithubOutput:
+		w := o.writer()
+		if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		for _, r := range o.results {
+			for _, f := range r.fields() {
+				key := f.key
+				if r.Package != "" {
+					key = r.Package + "-" + key
+				}
+				if err := writeGithubOutput(w, key, f.value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// result is the outcome of a command for a single package
+type result struct {
+	Package        string          `json:"package,omitempty"`
+	CurrentVersion string          `json:"currentVersion"`
+	CurrentTag     string          `json:"currentTag"`
+	NextVersion    string          `json:"nextVersion,omitempty"`
+	NextTag        string          `json:"nextTag,omitempty"`
+	Bump           string          `json:"bump,omitempty"`
+	Release        *bool           `json:"release,omitempty"`
+	Commits        []*resultCommit `json:"commits,omitempty"`
+	Notes          string          `json:"notes,omitempty"`
+	CreatedTag     string          `json:"createdTag,omitempty"`
+	ReleaseURL     string          `json:"releaseURL,omitempty"`
+
+	// defaultTag is the current version in the configured format, written
+	// instead of an empty current tag by the flat formats
+	defaultTag string
+}
+
+type resultCommit struct {
+	Hash        string `json:"hash"`
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking"`
+	Bump        string `json:"bump"`
+}
+
+// newResult fills a result with the versions and commits of plan
+func newResult(cfg *semrel.Config, plan *semrel.Plan) (*result, error) {
+	format, err := cfg.TagFormat(plan.Package)
+	if err != nil {
+		return nil, err
+	}
+	defaultTag, err := format.Tag(plan.Current)
+	if err != nil {
+		return nil, err
+	}
+	needed := plan.Needed()
+	res := &result{
+		Package:        plan.Package.Name,
+		CurrentVersion: plan.Current.String(),
+		CurrentTag:     plan.CurrentTag,
+		NextVersion:    plan.Next.String(),
+		NextTag:        plan.NextTag,
+		Release:        &needed,
+		Bump:           "none",
+		defaultTag:     defaultTag,
+	}
+	if plan.Decision != nil {
+		res.Bump = plan.Decision.Applied.String()
+	}
+	for _, e := range plan.Changes {
+		if e.Commit == nil || e.Reverted {
+			continue
+		}
+		res.Commits = append(res.Commits, &resultCommit{
+			Hash:        e.Hash,
+			Type:        e.Commit.Type,
+			Scope:       e.Commit.Scope,
+			Description: e.Commit.Description,
+			Breaking:    e.Commit.IsBreaking(),
+			Bump:        e.Commit.BumpKind(cfg).String(),
+		})
+	}
+	return res, nil
+}
+
+// tag is the text output, the next tag if there is one, else the current
+func (r *result) tag() string {
+	if r.NextTag != "" {
+		return r.NextTag
+	}
+	return r.currentTag()
+}
+
+// currentTag is the tag found, else the current version in the configured
+// format
+func (r *result) currentTag() string {
+	if r.CurrentTag != "" {
+		return r.CurrentTag
+	}
+	return r.defaultTag
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+// fields are the flat values of env and github-output formats
+func (r *result) fields() []field {
+	fs := []field{
+		{"current-version", r.CurrentVersion},
+		{"current-tag", r.currentTag()},
+	}
+	if r.Release != nil {
+		fs = append(fs,
+			field{"next-version", r.NextVersion},
+			field{"next-tag", r.NextTag},
+			field{"bump", r.Bump},
+			field{"release", fmt.Sprint(*r.Release)},
+		)
+	}
+	if r.Notes != "" {
+		fs = append(fs, field{"notes", r.Notes})
+	}
+	if r.CreatedTag != "" {
+		fs = append(fs, field{"created-tag", r.CreatedTag})
+	}
+	if r.ReleaseURL != "" {
+		fs = append(fs, field{"release-url", r.ReleaseURL})
+	}
+	return fs
+}
+
+// envName turns a package name into a valid environment variable part
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// writeGithubOutput writes key, using a random delimiter for multiline values
+func writeGithubOutput(w io.Writer, key, value string) error {
+	if !strings.Contains(value, "\n") {
+		_, err := fmt.Fprintf(w, "%s=%s\n", key, value)
+		return err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	delim := "SEMREL_EOF_" + hex.EncodeToString(b)
+	_, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", key, delim, strings.TrimSuffix(value, "\n"), delim)
+	return err
+}
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+	"github.com/spf13/cobra"
+)
+
+// testRepo commits the messages, tagging the ones with a tag
+func testRepo(t *testing.T, commits [][2]string) *repository.Repo {
+	t.Helper()
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()}
+	for _, c := range commits {
+		h, err := w.Commit(c[0], &git.CommitOptions{Author: sig, AllowEmptyCommits: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c[1] != "" {
+			if _, err := r.CreateTag(c[1], h, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return repository.New(r, "/tmp/test")
+}
+
+func TestResultFoundTag(t *testing.T) {
+	// the tag has a v prefix, the configured format has none
+	repo := testRepo(t, [][2]string{{"feat: a", "v1.0.0"}, {"fix: b", ""}})
+	cfg, err := semrel.NewConfig(semrel.WithPrefix(""), semrel.WithPatchTypes("fix"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := newPipeline(repo, cfg, false).Plan(rootPackage(cfg), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := newResult(cfg, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CurrentTag != "v1.0.0" || res.NextTag != "1.0.1" {
+		t.Errorf("expected current tag v1.0.0 and next tag 1.0.1, got %s and %s", res.CurrentTag, res.NextTag)
+	}
+	for _, f := range res.fields() {
+		if f.key == "current-tag" && f.value != "v1.0.0" {
+			t.Errorf("expected current-tag v1.0.0, got %s", f.value)
+		}
+	}
+}
+
+func TestResultNoTag(t *testing.T) {
+	repo := testRepo(t, [][2]string{{"chore: a", ""}})
+	cfg, err := semrel.NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := newPipeline(repo, cfg, false).Plan(rootPackage(cfg), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := newResult(cfg, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no tag is found, only the flat formats fall back to the current version
+	if res.CurrentTag != "" {
+		t.Errorf("expected no current tag, got %s", res.CurrentTag)
+	}
+	for _, f := range res.fields() {
+		if f.key == "current-tag" && f.value != "0.0.0" {
+			t.Errorf("expected current-tag 0.0.0, got %s", f.value)
+		}
+	}
+}
+
+func TestOutputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		cmd     *cobra.Command
+		wantErr bool
+	}{
+		{"text", outputText, &cobra.Command{Use: "current"}, false},
+		{"json", outputJSON, &cobra.Command{Use: "current"}, false},
+		{"invalid", "yaml", &cobra.Command{Use: "current"}, true},
+		{"text only", outputText, &cobra.Command{Use: "explain", Annotations: textOnly}, false},
+		{"json on text only", outputJSON, &cobra.Command{Use: "explain", Annotations: textOnly}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &output{format: tt.format}
+			if err := o.validate(tt.cmd); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputFlush(t *testing.T) {
+	released := true
+	single := []*result{{CurrentVersion: "1.0.0", CurrentTag: "v1.0.0"}}
+	pkgs := []*result{
+		{Package: "api", CurrentVersion: "1.0.0", CurrentTag: "api/v1.0.0"},
+		{Package: "web-ui", CurrentVersion: "2.0.0", CurrentTag: "web-ui/v2.0.0", NextVersion: "2.1.0", NextTag: "web-ui/v2.1.0", Bump: "minor", Release: &released, Notes: "## Features\n\n- it's new\n"},
+	}
+	tests := []struct {
+		name    string
+		format  string
+		results []*result
+		want    string
+	}{
+		{
+			name:    "text",
+			format:  outputText,
+			results: single,
+			want:    "v1.0.0\n",
+		},
+		{
+			name:    "json single",
+			format:  outputJSON,
+			results: single,
+			want:    "{\n  \"currentVersion\": \"1.0.0\",\n  \"currentTag\": \"v1.0.0\"\n}\n",
+		},
+		{
+			name:    "json packages",
+			format:  outputJSON,
+			results: pkgs[:1],
+			want:    "[\n  {\n    \"package\": \"api\",\n    \"currentVersion\": \"1.0.0\",\n    \"currentTag\": \"api/v1.0.0\"\n  }\n]\n",
+		},
+		{
+			name:    "env single",
+			format:  outputEnv,
+			results: single,
+			want:    "SEMREL_CURRENT_VERSION='1.0.0'\nSEMREL_CURRENT_TAG='v1.0.0'\n",
+		},
+		{
+			name:    "env packages",
+			format:  outputEnv,
+			results: pkgs,
+			want: "SEMREL_API_CURRENT_VERSION='1.0.0'\n" +
+				"SEMREL_API_CURRENT_TAG='api/v1.0.0'\n" +
+				"SEMREL_WEB_UI_CURRENT_VERSION='2.0.0'\n" +
+				"SEMREL_WEB_UI_CURRENT_TAG='web-ui/v2.0.0'\n" +
+				"SEMREL_WEB_UI_NEXT_VERSION='2.1.0'\n" +
+				"SEMREL_WEB_UI_NEXT_TAG='web-ui/v2.1.0'\n" +
+				"SEMREL_WEB_UI_BUMP='minor'\n" +
+				"SEMREL_WEB_UI_RELEASE='true'\n" +
+				"SEMREL_WEB_UI_NOTES='## Features\n\n- it'\\''s new\n'\n",
+		},
+		{
+			name:    "github-output single",
+			format:  outputGithubOutput,
+			results: single,
+			want:    "current-version=1.0.0\ncurrent-tag=v1.0.0\n",
+		},
+		{
+			name:    "github-output packages",
+			format:  outputGithubOutput,
+			results: pkgs,
+			want: "api-current-version=1.0.0\n" +
+				"api-current-tag=api/v1.0.0\n" +
+				"web-ui-current-version=2.0.0\n" +
+				"web-ui-current-tag=web-ui/v2.0.0\n" +
+				"web-ui-next-version=2.1.0\n" +
+				"web-ui-next-tag=web-ui/v2.1.0\n" +
+				"web-ui-bump=minor\n" +
+				"web-ui-release=true\n" +
+				"web-ui-notes<<EOF\n## Features\n\n- it's new\nEOF\n",
+		},
+	}
+	// the random delimiters of multiline values are replaced to compare
+	delim := regexp.MustCompile(`SEMREL_EOF_[0-9a-f]{16}`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_OUTPUT", "")
+			b := &bytes.Buffer{}
+			o := &output{format: tt.format, stdout: b}
+			for _, r := range tt.results {
+				o.add(r)
+			}
+			if err := o.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := delim.ReplaceAllString(b.String(), "EOF"); got != tt.want {
+				t.Errorf("expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutputFlushGithubOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(path, []byte("previous=step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_OUTPUT", path)
+	b := &bytes.Buffer{}
+	o := &output{format: outputGithubOutput, stdout: b}
+	o.add(&result{CurrentVersion: "1.0.0", CurrentTag: "v1.0.0"})
+	if err := o.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", b.String())
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "previous=step\ncurrent-version=1.0.0\ncurrent-tag=v1.0.0\n"
+	if string(got) != want {
+		t.Errorf("expected %q, got %q", want, string(got))
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"1.0.0", "'1.0.0'"},
+		{"$HOME `id`", "'$HOME `id`'"},
+		{"it's", `'it'\''s'`},
+		{"a\nb", "'a\nb'"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"api", "api"},
+		{"web-ui", "web_ui"},
+		{"tools/cli.v2", "tools_cli_v2"},
+		{"Äpi", "_pi"},
+	}
+	for _, tt := range tests {
+		if got := envName(tt.in); got != tt.want {
+			t.Errorf("envName(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteGithubOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		lines []string
+	}{
+		{"single line", "next-tag", "v1.1.0", nil},
+		{"empty", "current-tag", "", nil},
+		{"multiline", "notes", "## Features\n\n- a\n", []string{"## Features", "", "- a"}},
+		{"no trailing newline", "notes", "a\nb", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := writeGithubOutput(b, tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if tt.lines == nil {
+				if want := tt.key + "=" + tt.value + "\n"; b.String() != want {
+					t.Errorf("expected %q, got %q", want, b.String())
+				}
+				return
+			}
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			key, delim, ok := strings.Cut(lines[0], "<<")
+			if !ok || key != tt.key || !strings.HasPrefix(delim, "SEMREL_EOF_") {
+				t.Fatalf("expected a heredoc of %s, got %q", tt.key, lines[0])
+			}
+			if last := lines[len(lines)-1]; last != delim {
+				t.Errorf("expected the closing delimiter %s, got %q", delim, last)
+			}
+			if got := lines[1 : len(lines)-1]; strings.Join(got, "\n") != strings.Join(tt.lines, "\n") {
+				t.Errorf("expected lines %q, got %q", tt.lines, got)
+			}
+		})
+	}
+
+	// the delimiter is random, so that values cannot end the heredoc
+	a, b := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := writeGithubOutput(a, "notes", "a\nb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeGithubOutput(b, "notes", "a\nb"); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() == b.String() {
+		t.Error("expected different delimiters")
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// selectPackages returns the packages to operate on. Without configured
+// packages, the whole repository is versioned as a single unnamed package.
+func selectPackages(cfg *semrel.Config, name string) ([]semrel.Package, error) {
+	if name != "" {
+		pkg, err := cfg.Package(name)
+		if err != nil {
+			return nil, err
+		}
+		return []semrel.Package{pkg}, nil
+	}
+	if len(cfg.Packages()) == 0 {
+		return []semrel.Package{rootPackage(cfg)}, nil
+	}
+	return cfg.Packages(), nil
+}
+
+// rootPackage is the whole repository, versioned with the configured prefix
+func rootPackage(cfg *semrel.Config) semrel.Package {
+	return semrel.Package{Prefix: cfg.Prefix()}
+}
+
+// logOptions narrows the commits to the ones of pkg, following the first
+// parent only if configured, parsed by the configured parser
+func logOptions(cfg *semrel.Config, pkg semrel.Package) *repository.LogOptions {
+	opts := &repository.LogOptions{FirstParent: cfg.FirstParent(), Parser: cfg.CommitParser()}
+	if pkg.Path != "" {
+		opts.Paths = []string{pkg.Path}
+	}
+	return opts
+}
+
+// releaseBranch returns the configured branch of the checked out branch,
+// with the prerelease flag as its channel if set. SEMREL_BRANCH overrides the
+// branch, both to match the configured branches and as the target of platform
+// releases. On a detached HEAD, the branch of GitLab CI or GitHub Actions is
+// used. If branches are configured, releasing from any other is an error.
+func releaseBranch(repo *repository.Repo, cfg *semrel.Config, prerelease string, release bool) (*semrel.Branch, error) {
+	name := os.Getenv("SEMREL_BRANCH")
+	if name == "" {
+		var err error
+		name, err = repo.Branch()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if name == "" {
+		name = ciBranch()
+	}
+	branch := &semrel.Branch{Name: name}
+	if b := cfg.Branch(name); b != nil {
+		*branch = *b
+	} else if release && len(cfg.Branches()) > 0 {
+		if name == "" {
+			return nil, errors.New("HEAD is detached, set SEMREL_BRANCH to release from a configured branch")
+		}
+		return nil, fmt.Errorf("branch %s does not match any configured branch to release from", name)
+	}
+	if prerelease != "" {
+		branch.Prerelease = prerelease
+	}
+	return branch, nil
+}
+
+// ciBranch returns the branch the CI pipeline runs for, which checks out a
+// detached HEAD, empty if unknown
+func ciBranch() string {
+	for _, env := range []string{"CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME", "GITHUB_REF_NAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// newPipeline returns a pipeline reading the versions and commits of the
+// repository
+func newPipeline(repo *repository.Repo, cfg *semrel.Config, currentBranchOnly bool) *semrel.Pipeline {
+	src := &repoSource{repo: repo, cfg: cfg, currentBranchOnly: currentBranchOnly}
+	return &semrel.Pipeline{
+		Config:   cfg,
+		Versions: src,
+		Commits:  src,
+	}
+}
+
+// repoSource reads the versions and commits of the repository
+type repoSource struct {
+	repo              *repository.Repo
+	cfg               *semrel.Config
+	currentBranchOnly bool
+}
+
+func (s *repoSource) Versions(format *semrel.TagFormat) ([]semrel.Release, error) {
+	versions, err := s.repo.Versions(format, s.currentBranchOnly)
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]semrel.Release, len(versions))
+	for i, v := range versions {
+		releases[i] = semrel.Release{Version: v.Version, Tag: v.Ref.Name().Short(), Commit: v.Commit.String()}
+	}
+	return releases, nil
+}
+
+func (s *repoSource) Commits(pkg semrel.Package, since string) ([]semrel.Change, error) {
+	log, err := s.repo.Log(plumbing.ZeroHash, plumbing.NewHash(since), logOptions(s.cfg, pkg))
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]semrel.Change, len(log))
+	for i, e := range log {
+		changes[i] = semrel.Change{Hash: e.Hash.String(), Subject: e.Subject, Commit: e.Commit, Reverted: e.Reverted}
+	}
+	return changes, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// detachedRepo returns a repository with HEAD detached at its only commit
+func detachedRepo(t *testing.T) *repository.Repo {
+	t.Helper()
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()}
+	h, err := w.Commit("feat: a", &git.CommitOptions{Author: sig, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, h)); err != nil {
+		t.Fatal(err)
+	}
+	return repository.New(r, "/tmp/test")
+}
+
+func TestReleaseBranch(t *testing.T) {
+	cfg, err := semrel.NewConfig(semrel.WithBranches(
+		semrel.Branch{Name: "main"},
+		semrel.Branch{Name: "next", Prerelease: "beta"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		env        map[string]string
+		release    bool
+		want       string
+		prerelease string
+		wantErr    bool
+	}{
+		{name: "detached", release: true, wantErr: true},
+		{name: "detached without release", release: false, want: ""},
+		{name: "semrel branch", env: map[string]string{"SEMREL_BRANCH": "next", "GITHUB_REF_NAME": "main"}, release: true, want: "next", prerelease: "beta"},
+		{name: "gitlab branch", env: map[string]string{"CI_COMMIT_BRANCH": "next", "CI_COMMIT_REF_NAME": "main"}, release: true, want: "next", prerelease: "beta"},
+		{name: "gitlab ref", env: map[string]string{"CI_COMMIT_REF_NAME": "main"}, release: true, want: "main"},
+		{name: "github ref", env: map[string]string{"GITHUB_REF_NAME": "main"}, release: true, want: "main"},
+		{name: "unconfigured", env: map[string]string{"GITHUB_REF_NAME": "feature"}, release: true, wantErr: true},
+		{name: "unconfigured without release", env: map[string]string{"GITHUB_REF_NAME": "feature"}, release: false, want: "feature"},
+	}
+	repo := detachedRepo(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{"SEMREL_BRANCH", "CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME", "GITHUB_REF_NAME"} {
+				t.Setenv(env, tt.env[env])
+			}
+			branch, err := releaseBranch(repo, cfg, "", tt.release)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("releaseBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if branch.Name != tt.want || branch.Prerelease != tt.prerelease {
+				t.Errorf("expected branch %q with prerelease %q, got %q with %q", tt.want, tt.prerelease, branch.Name, branch.Prerelease)
+			}
+		})
+	}
+}
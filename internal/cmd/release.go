@@ -3,9 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/Masterminds/semver/v3"
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/greatliontech/semrel/internal/release"
 	"github.com/greatliontech/semrel/internal/repository"
 	"github.com/greatliontech/semrel/pkg/semrel"
@@ -19,92 +19,183 @@ type releaseCommand struct {
 	prerelease        string
 	build             string
 	currentBranchOnly bool
+	pkg               string
+	dryRun            bool
+	assets            []string
+	draft             bool
+	latest            bool
+	onExisting        string
+	// remote is the persistent remote flag of the root command
+	remote *string
+	out    *output
 }
 
-func newReleaseCommand(repo *repository.Repo, cfg *semrel.Config) *releaseCommand {
+func newReleaseCommand(repo *repository.Repo, cfg *semrel.Config, out *output, remote *string) *releaseCommand {
 	c := &releaseCommand{
-		repo: repo,
-		cfg:  cfg,
+		repo:   repo,
+		cfg:    cfg,
+		out:    out,
+		remote: remote,
 	}
 	cmd := &cobra.Command{
 		Use:   "release",
 		Short: "Release a new version",
-		RunE:  c.runE,
+		Long: `Release a new version on the configured platform, which creates the tag at HEAD.
+
+The configured files are not bumped, use semrel --create-tag for that and
+release the pushed tag.
+
+SEMREL_BRANCH overrides the checked out branch, both to select the configured
+branch and as the target branch of the platform release.`,
+		RunE: c.runE,
 	}
-	cmd.Flags().StringVarP(&c.prerelease, "prerelease", "p", "", "prerelease version")
+	cmd.Flags().StringVarP(&c.prerelease, "prerelease", "p", "", "prerelease channel, e.g. rc for numbered rc.1, rc.2, ...")
 	cmd.Flags().StringVarP(&c.build, "build", "b", "", "build version")
 	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only tags from the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "only the given package")
+	cmd.Flags().BoolVarP(&c.dryRun, "dry-run", "", false, "explain the next version and print the notes without creating the release")
+	cmd.Flags().StringArrayVarP(&c.assets, "asset", "", nil, "glob of files to upload with the release, optionally labeled as <glob>#<label>")
+	cmd.Flags().BoolVarP(&c.draft, "draft", "", false, "create the release as a draft")
+	cmd.Flags().BoolVarP(&c.latest, "latest", "", true, "mark the release as the latest, prereleases never are")
+	cmd.Flags().StringVarP(&c.onExisting, "on-existing", "", "", "if the release exists: fail, skip or update its notes and missing assets, defaults to fail")
 	c.cmd = cmd
 	return c
 }
 
 func (r *releaseCommand) runE(cmd *cobra.Command, args []string) error {
-	var next semver.Version
-	// check for initial version
-	if r.cfg.InitialVersion() != nil {
-		next = *r.cfg.InitialVersion()
+	switch r.onExisting {
+	case "", semrel.OnExistingFail, semrel.OnExistingSkip, semrel.OnExistingUpdate:
+	default:
+		return fmt.Errorf("invalid --on-existing %q, expected %s, %s or %s", r.onExisting, semrel.OnExistingFail, semrel.OnExistingSkip, semrel.OnExistingUpdate)
 	}
-
-	// get latest tag version
-	current, ref, err := r.repo.CurrentVersion(r.currentBranchOnly)
+	pkgs, err := selectPackages(r.cfg, r.pkg)
 	if err != nil {
 		return err
 	}
-
-	commits := []*semrel.Commit{}
-	if !current.Equal(emptyVersion) {
-		if ref != nil {
-			commits, err = r.repo.Commits(plumbing.ZeroHash, ref.Hash())
-			if err != nil {
-				return err
-			}
+	for _, pkg := range pkgs {
+		res, err := r.runPackage(pkg)
+		if err != nil {
+			return err
 		}
-		next = semrel.NextVersion(current, commits, r.cfg)
+		r.out.add(res)
 	}
+	return r.out.flush()
+}
 
-	if next.Equal(current) {
-		currentTag := fmt.Sprintf("%s%s", r.cfg.Prefix(), current.String())
-		fmt.Println(currentTag)
-		return nil
+func (r *releaseCommand) runPackage(pkg semrel.Package) (*result, error) {
+	branch, err := releaseBranch(r.repo, r.cfg, r.prerelease, !r.dryRun)
+	if err != nil {
+		return nil, err
 	}
-
-	if r.prerelease != "" {
-		next, err = next.SetPrerelease(r.prerelease)
-		if err != nil {
-			return err
+	links, err := notesLinks(r.repo, r.cfg, *r.remote)
+	if err != nil {
+		return nil, err
+	}
+	p := newPipeline(r.repo, r.cfg, r.currentBranchOnly)
+	p.Notes = func(plan *semrel.Plan) (string, error) {
+		opts := &release.NotesOptions{Links: links, Tag: plan.NextTag, Contributors: true}
+		if plan.Released != nil {
+			opts.Previous = plan.Released.Tag
 		}
+		return releaseNotes(r.repo.Root(), r.cfg, plan.Commits, opts)
+	}
+	plan, err := p.Plan(pkg, &semrel.PlanOptions{Branch: branch, Build: r.build})
+	if err != nil {
+		return nil, err
 	}
 
-	if r.build != "" {
-		next, err = next.SetMetadata(r.build)
-		if err != nil {
-			return err
+	if r.dryRun {
+		if err := explainVersion(r.out.explainWriter(), r.cfg, plan); err != nil {
+			return nil, err
 		}
 	}
 
-	nextTag := fmt.Sprintf("%s%s", r.cfg.Prefix(), next.String())
+	res, err := newResult(r.cfg, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Needed() {
+		return res, nil
+	}
+	res.Notes = plan.Notes
 
-	var filters *release.Filters
-	if r.cfg.Filters() != nil {
-		filters = &release.Filters{}
-		filters.Types = r.cfg.Filters().Types
-		filters.Scopes = r.cfg.Filters().Scopes
+	assets, err := r.releaseAssets()
+	if err != nil {
+		return nil, err
 	}
 
-	rules := []*release.MatchRule{}
-	if len(r.cfg.MatchRules()) > 0 {
-		for _, rule := range r.cfg.MatchRules() {
-			r, err := release.NewMatchRule(rule.Match, rule.Replace)
-			if err != nil {
-				return fmt.Errorf("invalid match rule: %w", err)
+	if r.dryRun {
+		if r.out.isText() {
+			fmt.Printf("notes:\n%s", plan.Notes)
+			if len(assets) > 0 {
+				fmt.Println("assets:")
+			}
+			for _, a := range assets {
+				path, err := filepath.Rel(r.repo.Root(), a.Path)
+				if err != nil {
+					path = a.Path
+				}
+				fmt.Printf("  %s\n", path)
 			}
-			rules = append(rules, r)
 		}
+		return res, nil
 	}
 
-	notes := release.GenerateReleaseNotes(commits, filters, rules)
+	if len(assets) > 0 {
+		dir, err := os.MkdirTemp("", "semrel-assets-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		sums, err := release.WriteChecksums(dir, assets)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, sums)
+	}
+	p.ReleaseOptions = semrel.ReleaseOptions{
+		Assets:     assets,
+		Draft:      r.draft || r.cfg.Draft(),
+		Latest:     r.cfg.Latest(),
+		OnExisting: r.cfg.OnExisting(),
+	}
+	if r.cmd.Flags().Changed("latest") {
+		p.ReleaseOptions.Latest = &r.latest
+	}
+	if r.onExisting != "" {
+		p.ReleaseOptions.OnExisting = r.onExisting
+	}
+	// the analyzed commit, the branch may have moved since
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	p.ReleaseOptions.Target = head.String()
+
+	p.Releaser, err = r.releaser()
+	if err != nil {
+		return nil, err
+	}
+	res.ReleaseURL, err = p.Publish(plan)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	platform, tok, proj, err := release.DetectPlatform()
+// releaseAssets returns the files of the configured and flagged assets
+func (r *releaseCommand) releaseAssets() ([]semrel.Asset, error) {
+	assets := append([]semrel.Asset{}, r.cfg.Assets()...)
+	for _, a := range r.assets {
+		path, label, _ := strings.Cut(a, "#")
+		assets = append(assets, semrel.Asset{Path: path, Label: label})
+	}
+	return release.ResolveAssets(r.repo.Root(), assets)
+}
+
+// releaser returns the releaser of the detected or configured platform
+func (r *releaseCommand) releaser() (release.Releaser, error) {
+	platform, url, tok, proj, err := release.DetectPlatform()
 	// only one type of error here, release.ErrPlatformDetectionFailed
 	if err != nil {
 		platform = r.cfg.Platform()
@@ -113,29 +204,27 @@ func (r *releaseCommand) runE(cmd *cobra.Command, args []string) error {
 			platform = p
 		}
 		if platform == "" {
-			return fmt.Errorf("platform not specified and could not be detected, please set SEMREL_PLATFORM environment variable or configure platform in semrel config: %w", err)
+			return nil, fmt.Errorf("platform not specified and could not be detected, please set SEMREL_PLATFORM environment variable or configure platform in semrel config: %w", err)
 		}
 	}
 
+	if url == "" {
+		url = r.cfg.PlatformURL()
+	}
+
 	// check for overrides from env
+	if u := os.Getenv("SEMREL_PLATFORM_URL"); u != "" {
+		url = u
+	}
 	if t := os.Getenv("SEMREL_TOKEN"); t != "" {
 		tok = t
 	}
 	if p := os.Getenv("SEMREL_PROJECT"); p != "" {
 		proj = p
 	}
-	// branch is explicitly set or empty
-	branch := os.Getenv("SEMREL_BRANCH")
-
-	releaser, err := release.Platform(platform, tok, proj, branch)
-	if err != nil {
-		return err
-	}
-
-	if err := releaser.Release(nextTag, notes); err != nil {
-		return fmt.Errorf("could not create release for next %q (current %q): %w", nextTag, current.String(), err)
-	}
+	// target branch is explicitly set or empty, the same SEMREL_BRANCH that
+	// selects the configured branch
+	target := os.Getenv("SEMREL_BRANCH")
 
-	fmt.Println(nextTag)
-	return nil
+	return release.Platform(platform, url, tok, proj, target)
 }
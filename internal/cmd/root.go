@@ -1,14 +1,8 @@
 package cmd
 
 import (
-	"fmt"
 	"log/slog"
-	"os"
 
-	"github.com/Masterminds/semver/v3"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/greatliontech/semrel/internal/repository"
 	"github.com/greatliontech/semrel/pkg/semrel"
 	"github.com/spf13/cobra"
@@ -21,17 +15,21 @@ type rootCommand struct {
 	currentBranchOnly bool
 	createTag         bool
 	pushTag           bool
-	authUsername      string
-	authPassword      string
-	authToken         string
+	auth              authFlags
 	prerelease        string
 	build             string
+	pkg               string
+	dryRun            bool
+	tag               tagFlags
+	out               *output
 }
 
 func New(rp *repository.Repo, cfg *semrel.Config, ver string) (*rootCommand, error) {
+	out := &output{}
 	c := &rootCommand{
 		repo: rp,
 		cfg:  cfg,
+		out:  out,
 	}
 	cmd := &cobra.Command{
 		Use:           "semrel",
@@ -39,23 +37,42 @@ func New(rp *repository.Repo, cfg *semrel.Config, ver string) (*rootCommand, err
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		Version:       ver,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return out.validate(cmd)
+		},
 	}
-	cmd.Flags().StringVarP(&c.prerelease, "prerelease", "p", "", "prerelease version")
+	cmd.PersistentFlags().StringVarP(&out.format, "output", "o", outputText, "output format: text, json, env or github-output")
+	cmd.Flags().StringVarP(&c.prerelease, "prerelease", "p", "", "prerelease channel, e.g. rc for numbered rc.1, rc.2, ...")
 	cmd.Flags().StringVarP(&c.build, "build", "b", "", "build version")
 	cmd.Flags().BoolVarP(&c.currentBranchOnly, "current-branch-only", "", false, "only tags from the current branch")
+	cmd.Flags().StringVarP(&c.pkg, "package", "", "", "only the given package")
+	cmd.Flags().BoolVarP(&c.dryRun, "dry-run", "", false, "explain the next version without creating or pushing the tag")
 	cmd.Flags().BoolVarP(&c.createTag, "create-tag", "", false, "create the tag")
 	cmd.Flags().BoolVarP(&c.pushTag, "push-tag", "", false, "push the tag")
-	cmd.Flags().StringVarP(&c.authUsername, "auth-username", "", "", "username for basic auth")
-	cmd.Flags().StringVarP(&c.authPassword, "auth-password", "", "", "password for basic auth")
+	cmd.Flags().BoolVarP(&c.tag.annotate, "annotate", "", false, "create an annotated tag")
+	cmd.Flags().StringVarP(&c.tag.message, "tag-message", "", "", "message of the annotated tag, defaults to the release notes")
+	cmd.Flags().StringVarP(&c.tag.signKey, "sign-key", "", "", "sign the tag with the private key file")
+	cmd.Flags().StringVarP(&c.tag.signFormat, "sign-format", "", "openpgp", "format of the signing key: openpgp or ssh")
+	cmd.PersistentFlags().StringVarP(&c.auth.remote, "remote", "", "origin", "remote to push to, and to link release notes to")
+	cmd.Flags().StringVarP(&c.auth.username, "auth-username", "", "", "username for basic auth")
+	cmd.Flags().StringVarP(&c.auth.password, "auth-password", "", "", "password for basic auth")
 	cmd.MarkFlagsRequiredTogether("auth-username", "auth-password")
-	cmd.Flags().StringVarP(&c.authToken, "auth-token", "", "", "token for auth")
+	cmd.Flags().StringVarP(&c.auth.token, "auth-token", "", "", "token for auth")
 	cmd.MarkFlagsMutuallyExclusive("auth-username", "auth-token")
 	cmd.MarkFlagsMutuallyExclusive("auth-password", "auth-token")
+	cmd.Flags().StringVarP(&c.auth.sshKey, "auth-ssh-key", "", "", "private key file for ssh auth, else the ssh agent is used")
+	cmd.Flags().StringVarP(&c.auth.sshPassphrase, "auth-ssh-passphrase", "", "", "passphrase of the ssh key")
+	cmd.Flags().StringVarP(&c.auth.knownHosts, "known-hosts", "", "", "known_hosts file for ssh, defaults to SSH_KNOWN_HOSTS or ~/.ssh/known_hosts")
+	cmd.Flags().BoolVarP(&c.auth.insecureIgnoreHostKey, "insecure-ignore-host-key", "", false, "do not verify the ssh host key")
+	cmd.MarkFlagsMutuallyExclusive("known-hosts", "insecure-ignore-host-key")
 	cmd.AddCommand(
-		newCurrentCommand(rp, cfg).cmd,
-		newCompareCommand(rp).cmd,
+		newCurrentCommand(rp, cfg, out).cmd,
+		newCompareCommand(rp, cfg).cmd,
 		newValidateCommand().cmd,
-		newReleaseCommand(rp, cfg).cmd,
+		newReleaseCommand(rp, cfg, out, &c.auth.remote).cmd,
+		newChangelogCommand(rp, cfg, &c.auth.remote).cmd,
+		newExplainCommand(rp, cfg).cmd,
+		newLintCommand(rp, cfg).cmd,
 	)
 	c.cmd = cmd
 	return c, nil
@@ -64,7 +81,7 @@ func New(rp *repository.Repo, cfg *semrel.Config, ver string) (*rootCommand, err
 func (r *rootCommand) Execute() int {
 	err := r.cmd.Execute()
 	if err != nil {
-		if err != errCompareFailed {
+		if err != errCompareFailed && err != errLintFailed {
 			slog.Error("command failed", "error", err)
 		}
 		return 1
@@ -72,92 +89,58 @@ func (r *rootCommand) Execute() int {
 	return 0
 }
 
-var emptyVersion = semver.New(0, 0, 0, "", "")
-
 func (r *rootCommand) runE(cmd *cobra.Command, args []string) error {
-	var next semver.Version
-	// check for initial version
-	if r.cfg.InitialVersion() != nil {
-		next = *r.cfg.InitialVersion()
-	}
-
-	// get latest tag version
-	current, ref, err := r.repo.CurrentVersion(r.currentBranchOnly)
+	pkgs, err := selectPackages(r.cfg, r.pkg)
 	if err != nil {
 		return err
 	}
-
-	if !current.Equal(emptyVersion) {
-		commits := []*semrel.Commit{}
-		if ref != nil {
-			commits, err = r.repo.Commits(plumbing.ZeroHash, ref.Hash())
-			if err != nil {
-				return err
-			}
-		}
-		next = semrel.NextVersion(current, commits, r.cfg)
-	}
-
-	if next.Equal(current) {
-		currentTag := fmt.Sprintf("%s%s", r.cfg.Prefix(), current.String())
-		fmt.Println(currentTag)
-		return nil
-	}
-
-	if r.prerelease != "" {
-		next, err = next.SetPrerelease(r.prerelease)
+	for _, pkg := range pkgs {
+		res, err := r.runPackage(pkg)
 		if err != nil {
 			return err
 		}
+		r.out.add(res)
 	}
+	return r.out.flush()
+}
 
-	if r.build != "" {
-		next, err = next.SetMetadata(r.build)
-		if err != nil {
-			return err
-		}
+func (r *rootCommand) runPackage(pkg semrel.Package) (*result, error) {
+	// only creating the tag requires a branch to release from
+	create := (r.createTag || r.cfg.CreateTag()) && !r.dryRun
+	branch, err := releaseBranch(r.repo, r.cfg, r.prerelease, create)
+	if err != nil {
+		return nil, err
 	}
-
-	nextTag := fmt.Sprintf("%s%s", r.cfg.Prefix(), next.String())
-
-	if r.createTag || r.cfg.CreateTag() {
-		head, err := r.repo.Head()
-		if err != nil {
-			return err
-		}
-
-		var auth transport.AuthMethod
-		if un := os.Getenv("SEMREL_AUTH_USERNAME"); un != "" {
-			r.authUsername = un
-		}
-		if pw := os.Getenv("SEMREL_AUTH_PASSWORD"); pw != "" {
-			r.authPassword = pw
-		}
-		if tok := os.Getenv("SEMREL_AUTH_TOKEN"); tok != "" {
-			r.authToken = tok
-		}
-
-		if r.authUsername != "" && r.authPassword != "" {
-			auth = &http.BasicAuth{
-				Username: r.authUsername,
-				Password: r.authPassword,
-			}
-		}
-
-		if r.authToken != "" {
-			auth = &http.BasicAuth{
-				Username: "git",
-				Password: r.authToken,
-			}
+	p := newPipeline(r.repo, r.cfg, r.currentBranchOnly)
+	if create {
+		p.Tagger = &repoTagger{
+			repo:  r.repo,
+			cfg:   r.cfg,
+			flags: &r.tag,
+			auth:  &r.auth,
+			push:  r.pushTag || r.cfg.PushTag(),
 		}
+	}
+	plan, err := p.Plan(pkg, &semrel.PlanOptions{Branch: branch, Build: r.build})
+	if err != nil {
+		return nil, err
+	}
 
-		pushTag := r.pushTag || r.cfg.PushTag()
-		err = r.repo.CreateTag(nextTag, head, pushTag, auth)
-		if err != nil {
-			return err
+	if r.dryRun {
+		if err := explainVersion(r.out.explainWriter(), r.cfg, plan); err != nil {
+			return nil, err
 		}
 	}
 
-	fmt.Println(nextTag)
-	return nil
+	res, err := newResult(r.cfg, plan)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Publish(plan); err != nil {
+		return nil, err
+	}
+	if create && plan.Needed() {
+		res.CreatedTag = plan.NextTag
+	}
+	return res, nil
 }
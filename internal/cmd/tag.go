@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/greatliontech/semrel/internal/repository"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// tagFlags are the flags of annotated and signed tags
+type tagFlags struct {
+	annotate   bool
+	message    string
+	signKey    string
+	signFormat string
+}
+
+// tagOptions returns the options of the tag, or nil for a lightweight tag.
+// Tags are annotated if asked for or signed. The message is the flag, else the
+// configured message, else the release notes of commits, else the tag itself.
+func (f *tagFlags) tagOptions(root string, cfg *semrel.Config, tag string, commits []*semrel.Commit) (*repository.TagOptions, error) {
+	signing := cfg.Signing()
+	if f.signKey != "" {
+		signing = &semrel.Signing{Format: f.signFormat, Key: f.signKey}
+	}
+	if !f.annotate && !cfg.AnnotateTag() && signing == nil {
+		return nil, nil
+	}
+
+	opts := &repository.TagOptions{Message: f.message}
+	if opts.Message == "" {
+		opts.Message = cfg.TagMessage()
+	}
+	if opts.Message == "" {
+		notes, err := releaseNotes(root, cfg, commits, nil)
+		if err != nil {
+			return nil, err
+		}
+		opts.Message = notes
+	}
+	if opts.Message == "" {
+		opts.Message = tag
+	}
+
+	if t := cfg.Tagger(); t != nil && t.Name != "" && t.Email != "" {
+		opts.Tagger = &object.Signature{Name: t.Name, Email: t.Email, When: time.Now()}
+	}
+
+	if signing == nil {
+		return opts, nil
+	}
+	passphrase := os.Getenv("SEMREL_SIGNING_PASSPHRASE")
+	switch signing.Format {
+	case "", "openpgp":
+		key, err := repository.LoadPGPKey(signing.Key, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		opts.PGPKey = key
+	case "ssh":
+		signer, err := repository.LoadSSHSigner(signing.Key, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		opts.SSHSigner = signer
+	default:
+		return nil, fmt.Errorf("invalid signing format: %s", signing.Format)
+	}
+	return opts, nil
+}
+
+// repoTagger bumps the files of a plan, commits them if configured and tags
+// HEAD, pushing both if asked to
+type repoTagger struct {
+	repo  *repository.Repo
+	cfg   *semrel.Config
+	flags *tagFlags
+	auth  *authFlags
+	push  bool
+}
+
+func (t *repoTagger) CreateTag(plan *semrel.Plan) error {
+	// resolve the options first, so that nothing is committed on errors
+	opts, err := t.flags.tagOptions(t.repo.Root(), t.cfg, plan.NextTag, plan.Commits)
+	if err != nil {
+		return err
+	}
+
+	var push *repository.PushOptions
+	if t.push {
+		push, err = t.auth.pushOptions(t.repo)
+		if err != nil {
+			return err
+		}
+	}
+
+	// refuse before bumping, so that no commit is left without its tag
+	exists, err := t.repo.TagExists(plan.NextTag)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("tag %s already exists", plan.NextTag)
+	}
+
+	committed, err := bumpFiles(t.repo, t.cfg, plan.Package, &plan.Next, plan.NextTag)
+	if err != nil {
+		return err
+	}
+
+	head, err := t.repo.Head()
+	if err != nil {
+		return err
+	}
+
+	if push != nil {
+		push.Branch = committed
+	}
+	return t.repo.CreateTag(plan.NextTag, head, opts, push)
+}
@@ -17,10 +17,11 @@ type validateCommand struct {
 func newValidateCommand() *validateCommand {
 	c := &validateCommand{}
 	c.cmd = &cobra.Command{
-		Use:   "validate",
-		Short: "Validate a semver version string",
-		RunE:  c.runE,
-		Args:  cobra.ExactArgs(1),
+		Use:         "validate",
+		Short:       "Validate a semver version string",
+		RunE:        c.runE,
+		Annotations: textOnly,
+		Args:        cobra.ExactArgs(1),
 	}
 	c.cmd.Flags().BoolVar(&c.strict, "strict", false, "strict semver validation")
 	c.cmd.Flags().BoolVar(&c.noPreRelease, "noPrerelease", false, "do not allow pre-release versions")
@@ -0,0 +1,91 @@
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// ChecksumsFile is the name of the checksums asset
+const ChecksumsFile = "SHA256SUMS"
+
+// ResolveAssets expands the globs of assets relative to root into the files to
+// upload. Every glob must match a file, and the file names must be unique as
+// they name the assets of the release.
+func ResolveAssets(root string, assets []semrel.Asset) ([]semrel.Asset, error) {
+	files := []semrel.Asset{}
+	names := map[string]string{}
+	for _, a := range assets {
+		matches, err := filepath.Glob(filepath.Join(root, a.Path))
+		if err != nil {
+			return nil, fmt.Errorf("asset %s: %w", a.Path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("asset %s: no files match", a.Path)
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				return nil, fmt.Errorf("asset %s: %s is a directory", a.Path, m)
+			}
+			name := filepath.Base(m)
+			if name == ChecksumsFile {
+				return nil, fmt.Errorf("asset %s: %s is reserved for the checksums", a.Path, name)
+			}
+			if prev, ok := names[name]; ok {
+				if prev == m {
+					continue
+				}
+				return nil, fmt.Errorf("asset %s: %s and %s have the same name", a.Path, prev, m)
+			}
+			names[name] = m
+			files = append(files, semrel.Asset{Path: m, Label: a.Label})
+		}
+	}
+	return files, nil
+}
+
+// WriteChecksums writes the SHA-256 checksums of assets to the checksums file
+// in dir, in the format of sha256sum, and returns it as an asset
+func WriteChecksums(dir string, assets []semrel.Asset) (semrel.Asset, error) {
+	b := strings.Builder{}
+	for _, a := range assets {
+		sum, err := sha256File(a.Path)
+		if err != nil {
+			return semrel.Asset{}, err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, filepath.Base(a.Path))
+	}
+	path := filepath.Join(dir, ChecksumsFile)
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return semrel.Asset{}, err
+	}
+	return semrel.Asset{Path: path}, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// assetName is the name of the uploaded asset
+func assetName(a semrel.Asset) string {
+	return filepath.Base(a.Path)
+}
@@ -0,0 +1,88 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// writeAssets creates the files in a temporary directory and returns it
+func writeAssets(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveAssets(t *testing.T) {
+	dir := writeAssets(t, map[string]string{
+		"dist/app-linux.tar.gz":  "linux",
+		"dist/app-darwin.tar.gz": "darwin",
+		"dist/notes.txt":         "notes",
+		"other/app-linux.tar.gz": "other",
+	})
+
+	assets, err := ResolveAssets(dir, []semrel.Asset{
+		{Path: "dist/*.tar.gz", Label: "binary"},
+		{Path: "dist/app-linux.tar.gz"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("expected 2 assets, got %+v", assets)
+	}
+	if assets[0].Path != filepath.Join(dir, "dist/app-darwin.tar.gz") || assets[0].Label != "binary" {
+		t.Errorf("unexpected asset %+v", assets[0])
+	}
+
+	errs := map[string][]semrel.Asset{
+		"no match":       {{Path: "dist/*.zip"}},
+		"directory":      {{Path: "dist"}},
+		"duplicate name": {{Path: "*/app-linux.tar.gz"}},
+		"reserved name":  {{Path: "dist/" + ChecksumsFile}},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dist", ChecksumsFile), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, globs := range errs {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ResolveAssets(dir, globs); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriteChecksums(t *testing.T) {
+	dir := writeAssets(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	sums, err := WriteChecksums(t.TempDir(), []semrel.Asset{
+		{Path: filepath.Join(dir, "a.txt")},
+		{Path: filepath.Join(dir, "b.txt")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(sums.Path) != ChecksumsFile {
+		t.Errorf("expected %s, got %s", ChecksumsFile, sums.Path)
+	}
+	got, err := os.ReadFile(sums.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.txt\n" +
+		"3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d  b.txt\n"
+	if string(got) != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+}
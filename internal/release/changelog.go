@@ -0,0 +1,120 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChangelogStyle is the layout of the changelog file
+type ChangelogStyle string
+
+const (
+	// StyleKeepAChangelog follows https://keepachangelog.com
+	StyleKeepAChangelog ChangelogStyle = "keepachangelog"
+	// StyleConventional follows conventional-changelog
+	StyleConventional ChangelogStyle = "conventional"
+)
+
+func NewChangelogStyle(style string) (ChangelogStyle, error) {
+	switch s := ChangelogStyle(strings.ToLower(style)); s {
+	case "":
+		return StyleKeepAChangelog, nil
+	case StyleKeepAChangelog, StyleConventional:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid changelog style: %s", style)
+	}
+}
+
+// ChangelogEntry is the section of a single version in the changelog
+type ChangelogEntry struct {
+	Version    string
+	Date       time.Time
+	CompareURL string
+	Notes      string
+}
+
+func (s ChangelogStyle) header() string {
+	if s == StyleConventional {
+		return "# Changelog\n"
+	}
+	return `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+}
+
+// Entry renders the section of e
+func (s ChangelogStyle) Entry(e *ChangelogEntry) string {
+	b := strings.Builder{}
+	b.WriteString("## [")
+	b.WriteString(e.Version)
+	b.WriteString("]")
+	if e.CompareURL != "" {
+		b.WriteString("(")
+		b.WriteString(e.CompareURL)
+		b.WriteString(")")
+	}
+	date := e.Date.Format(time.DateOnly)
+	if s == StyleConventional {
+		b.WriteString(" (" + date + ")\n")
+	} else {
+		b.WriteString(" - " + date + "\n")
+	}
+	if e.Notes != "" {
+		b.WriteString("\n")
+		b.WriteString(e.Notes)
+	}
+	return b.String()
+}
+
+// PrependChangelog inserts the section of e above the newest version in
+// changelog, below an Unreleased section if any. An empty changelog gets the
+// header of the style. If changelog already has a section for the version, it
+// is returned unchanged.
+func PrependChangelog(changelog string, style ChangelogStyle, e *ChangelogEntry) string {
+	if changelog == "" {
+		changelog = style.header()
+	}
+	if strings.HasPrefix(changelog, "## ["+e.Version+"]") ||
+		strings.Contains(changelog, "\n## ["+e.Version+"]") {
+		return changelog
+	}
+	entry := style.Entry(e)
+	idx := strings.Index(changelog, "\n## ")
+	if idx >= 0 && isUnreleased(changelog[idx+1:]) {
+		next := strings.Index(changelog[idx+1:], "\n## ")
+		if next < 0 {
+			idx = -1
+		} else {
+			idx += 1 + next
+		}
+	}
+	if idx < 0 {
+		return strings.TrimRight(changelog, "\n") + "\n\n" + entry
+	}
+	return changelog[:idx+1] + entry + "\n" + changelog[idx+1:]
+}
+
+// isUnreleased reports whether section starts with the heading of unreleased
+// changes, "## [Unreleased]" in Keep a Changelog
+func isUnreleased(section string) bool {
+	heading, _, _ := strings.Cut(section, "\n")
+	heading = strings.Trim(strings.TrimSpace(strings.TrimPrefix(heading, "## ")), "[]")
+	return strings.EqualFold(heading, "unreleased")
+}
+
+// GenerateChangelog renders a complete changelog from entries, newest first
+func GenerateChangelog(style ChangelogStyle, entries []*ChangelogEntry) string {
+	b := strings.Builder{}
+	b.WriteString(style.header())
+	for _, e := range entries {
+		b.WriteString("\n")
+		b.WriteString(style.Entry(e))
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package release
+
+import (
+	"testing"
+	"time"
+)
+
+var testDate = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestChangelogPrependEmpty(t *testing.T) {
+	changelog := PrependChangelog("", StyleConventional, &ChangelogEntry{
+		Version:    "1.1.0",
+		Date:       testDate,
+		CompareURL: "https://example.com/compare/v1.0.0...v1.1.0",
+		Notes:      "- feat: new feature\n",
+	})
+	exp := `# Changelog
+
+## [1.1.0](https://example.com/compare/v1.0.0...v1.1.0) (2024-03-01)
+
+- feat: new feature
+`
+	if changelog != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, changelog)
+	}
+}
+
+func TestChangelogPrependExisting(t *testing.T) {
+	existing := `# Changelog
+
+Some intro.
+
+## [1.0.0] - 2024-01-01
+
+- feat: initial
+`
+	entry := &ChangelogEntry{
+		Version: "1.0.1",
+		Date:    testDate,
+		Notes:   "- fix: a bug\n",
+	}
+	changelog := PrependChangelog(existing, StyleKeepAChangelog, entry)
+	exp := `# Changelog
+
+Some intro.
+
+## [1.0.1] - 2024-03-01
+
+- fix: a bug
+
+## [1.0.0] - 2024-01-01
+
+- feat: initial
+`
+	if changelog != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, changelog)
+	}
+
+	if again := PrependChangelog(changelog, StyleKeepAChangelog, entry); again != changelog {
+		t.Errorf("expected changelog to be unchanged, got:\n%s", again)
+	}
+}
+
+func TestChangelogPrependUnreleased(t *testing.T) {
+	existing := `# Changelog
+
+## [Unreleased]
+
+- feat: upcoming
+
+## [1.0.0] - 2024-01-01
+
+- feat: initial
+`
+	entry := &ChangelogEntry{
+		Version: "1.0.1",
+		Date:    testDate,
+		Notes:   "- fix: a bug\n",
+	}
+	changelog := PrependChangelog(existing, StyleKeepAChangelog, entry)
+	exp := `# Changelog
+
+## [Unreleased]
+
+- feat: upcoming
+
+## [1.0.1] - 2024-03-01
+
+- fix: a bug
+
+## [1.0.0] - 2024-01-01
+
+- feat: initial
+`
+	if changelog != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, changelog)
+	}
+
+	// without released versions, the entry goes after the unreleased section
+	changelog = PrependChangelog("# Changelog\n\n## [Unreleased]\n", StyleKeepAChangelog, entry)
+	exp = `# Changelog
+
+## [Unreleased]
+
+## [1.0.1] - 2024-03-01
+
+- fix: a bug
+`
+	if changelog != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, changelog)
+	}
+}
+
+func TestChangelogGenerate(t *testing.T) {
+	changelog := GenerateChangelog(StyleKeepAChangelog, []*ChangelogEntry{
+		{Version: "1.1.0", Date: testDate, Notes: "- feat: new feature\n"},
+		{Version: "1.0.0", Date: testDate},
+	})
+	exp := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+
+## [1.1.0] - 2024-03-01
+
+- feat: new feature
+
+## [1.0.0] - 2024-03-01
+`
+	if changelog != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, changelog)
+	}
+}
+
+func TestChangelogStyle(t *testing.T) {
+	if s, err := NewChangelogStyle(""); err != nil || s != StyleKeepAChangelog {
+		t.Errorf("expected default style %s, got %s (%v)", StyleKeepAChangelog, s, err)
+	}
+	if _, err := NewChangelogStyle("unknown"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
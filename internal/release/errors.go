@@ -15,3 +15,11 @@ func (e *ErrUnsupportedPlatform) Error() string {
 }
 
 var ErrPlatformDetectionFailed = errors.New("failed to detect platform")
+
+// ErrReleaseExists is returned if the release of a tag exists and the policy
+// is to fail
+var ErrReleaseExists = errors.New("release already exists")
+
+// ErrTagMismatch is returned if the tag of a created release does not resolve
+// to the target commit
+var ErrTagMismatch = errors.New("tag does not point to the target commit")
@@ -0,0 +1,191 @@
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// BumpFile sets the version in the file at path, either at the dotted key of
+// a JSON, YAML or TOML file, or in the first capture group, or the one named
+// version, of every match of pattern.
+func BumpFile(path, key string, pattern *regexp.Regexp, version string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content, err = SetVersion(content, filepath.Ext(path), key, pattern, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return os.WriteFile(path, content, info.Mode())
+}
+
+// SetVersion sets the version in content, at key for the file extension ext,
+// or else by pattern. The rest of content is kept as is.
+func SetVersion(content []byte, ext, key string, pattern *regexp.Regexp, version string) ([]byte, error) {
+	if pattern != nil {
+		return setPatternVersion(content, pattern, version)
+	}
+	switch strings.ToLower(ext) {
+	case ".json", ".yaml", ".yml":
+		return setYAMLVersion(content, key, version)
+	case ".toml":
+		return setTOMLVersion(content, key, version)
+	default:
+		return nil, fmt.Errorf("keys are not supported in %q files, use a pattern", ext)
+	}
+}
+
+func setPatternVersion(content []byte, pattern *regexp.Regexp, version string) ([]byte, error) {
+	group := pattern.SubexpIndex("version")
+	if group < 0 {
+		group = 1
+	}
+	if pattern.NumSubexp() < group {
+		return nil, fmt.Errorf("pattern %s has no capture group", pattern)
+	}
+	matches := pattern.FindAllSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s does not match", pattern)
+	}
+	out := &bytes.Buffer{}
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		out.Write(content[last:start])
+		out.WriteString(version)
+		last = end
+	}
+	out.Write(content[last:])
+	return out.Bytes(), nil
+}
+
+// setYAMLVersion replaces the scalar at key in place, which also works for
+// JSON as it is a subset of YAML
+func setYAMLVersion(content []byte, key, version string) ([]byte, error) {
+	f, err := parser.ParseBytes(content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	p, err := yaml.PathString("$." + key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q: %w", key, err)
+	}
+	node, err := p.FilterFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("key %q not found", key)
+	}
+	tk := node.GetToken()
+	start := lineColumnOffset(content, tk.Position.Line, tk.Position.Column)
+	if start < 0 {
+		return nil, fmt.Errorf("key %q not found", key)
+	}
+	return replaceScalar(content, start, tk.Value, version)
+}
+
+// setTOMLVersion replaces the string at the dotted key, which is looked up in
+// the tables of the file line by line
+func setTOMLVersion(content []byte, key, version string) ([]byte, error) {
+	table := ""
+	offset := 0
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for sc.Scan() {
+		line := sc.Text()
+		lineStart := offset
+		offset += len(line) + 1
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = tomlKey(strings.Trim(trimmed[:end], "[]"))
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		full := tomlKey(name)
+		if table != "" {
+			full = table + "." + full
+		}
+		if full != key {
+			continue
+		}
+		start := lineStart + len(name) + 1 + len(value) - len(strings.TrimLeft(value, " \t"))
+		if start >= len(content) || (content[start] != '"' && content[start] != '\'') {
+			return nil, fmt.Errorf("key %q is not a string", key)
+		}
+		return replaceScalar(content, start, "", version)
+	}
+	return nil, fmt.Errorf("key %q not found", key)
+}
+
+// tomlKey normalizes a dotted TOML key, e.g. `tool . "poetry"` to tool.poetry
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// replaceScalar replaces the scalar starting at start, inside its quotes if
+// quoted, else the plain value
+func replaceScalar(content []byte, start int, value, version string) ([]byte, error) {
+	end := start + len(value)
+	if q := content[start]; q == '"' || q == '\'' {
+		closing := bytes.IndexByte(content[start+1:], q)
+		if closing < 0 {
+			return nil, fmt.Errorf("unterminated string at offset %d", start)
+		}
+		start++
+		end = start + closing
+	}
+	out := make([]byte, 0, len(content)+len(version))
+	out = append(out, content[:start]...)
+	out = append(out, version...)
+	return append(out, content[end:]...), nil
+}
+
+// lineColumnOffset returns the byte offset of the 1-based line and column,
+// counted in characters, or -1 if out of content
+func lineColumnOffset(content []byte, line, column int) int {
+	offset := 0
+	for i := 1; i < line; i++ {
+		nl := bytes.IndexByte(content[offset:], '\n')
+		if nl < 0 {
+			return -1
+		}
+		offset += nl + 1
+	}
+	for i := 1; i < column; i++ {
+		if offset >= len(content) || content[offset] == '\n' {
+			return -1
+		}
+		_, size := utf8.DecodeRune(content[offset:])
+		offset += size
+	}
+	if offset >= len(content) {
+		return -1
+	}
+	return offset
+}
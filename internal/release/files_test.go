@@ -0,0 +1,135 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSetVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		key     string
+		pattern string
+		content string
+		want    string
+	}{
+		{
+			name:    "package.json",
+			ext:     ".json",
+			key:     "version",
+			content: "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"dependencies\": {\"version\": \"9.9.9\"}\n}\n",
+			want:    "{\n  \"name\": \"app\",\n  \"version\": \"1.2.3\",\n  \"dependencies\": {\"version\": \"9.9.9\"}\n}\n",
+		},
+		{
+			name:    "Chart.yaml",
+			ext:     ".yaml",
+			key:     "appVersion",
+			content: "apiVersion: v2\n# the chart\nversion: 0.1.0 # chart version\nappVersion: \"1.0.0\"\n",
+			want:    "apiVersion: v2\n# the chart\nversion: 0.1.0 # chart version\nappVersion: \"1.2.3\"\n",
+		},
+		{
+			name:    "yaml plain nested",
+			ext:     ".yml",
+			key:     "image.tag",
+			content: "image:\n  name: app\n  tag: 1.0.0\n",
+			want:    "image:\n  name: app\n  tag: 1.2.3\n",
+		},
+		{
+			name:    "Cargo.toml",
+			ext:     ".toml",
+			key:     "package.version",
+			content: "[package]\nname = \"app\"\nversion = \"1.0.0\"\n\n[dependencies]\nversion = \"9.9.9\"\n",
+			want:    "[package]\nname = \"app\"\nversion = \"1.2.3\"\n\n[dependencies]\nversion = \"9.9.9\"\n",
+		},
+		{
+			name:    "pyproject.toml",
+			ext:     ".toml",
+			key:     "tool.poetry.version",
+			content: "[build-system]\nrequires = []\n\n[tool.poetry]\nversion = '1.0.0' # bumped\n",
+			want:    "[build-system]\nrequires = []\n\n[tool.poetry]\nversion = '1.2.3' # bumped\n",
+		},
+		{
+			name:    "version.go",
+			ext:     ".go",
+			pattern: `const Version = "(.*)"`,
+			content: "package app\n\nconst Version = \"1.0.0\"\n",
+			want:    "package app\n\nconst Version = \"1.2.3\"\n",
+		},
+		{
+			name:    "named group",
+			ext:     ".md",
+			pattern: `(image|app):(?P<version>[0-9.]+)`,
+			content: "docker pull image:1.0.0\ndocker run app:1.0.0\n",
+			want:    "docker pull image:1.2.3\ndocker run app:1.2.3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
+			got, err := SetVersion([]byte(tt.content), tt.ext, tt.key, pattern, "1.2.3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetVersionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		key     string
+		pattern string
+		content string
+	}{
+		{"missing key", ".json", "version", "", `{"name": "app"}`},
+		{"toml not a string", ".toml", "version", "", "version = 1\n"},
+		{"unsupported", ".go", "version", "", "package app\n"},
+		{"no match", ".go", "", `Version = "(.*)"`, "package app\n"},
+		{"no group", ".go", "", `Version`, "Version\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
+			if _, err := SetVersion([]byte(tt.content), tt.ext, tt.key, pattern, "1.2.3"); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestBumpFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(path, []byte(`{"version": "1.0.0"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpFile(path, "version", nil, "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"version": "2.0.0"}` {
+		t.Errorf("expected the version bumped, got %s", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %s", info.Mode())
+	}
+}
@@ -0,0 +1,182 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+var _ Releaser = (*giteaReleaser)(nil)
+
+var errGiteaNotFound = errors.New("not found")
+
+// giteaReleaser creates releases through the Gitea API, which Forgejo
+// implements as well
+type giteaReleaser struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	owner   string
+	repo    string
+	branch  string
+}
+
+type giteaRepository struct {
+	DefaultBranch string `json:"default_branch"`
+}
+
+type giteaRelease struct {
+	ID              int64  `json:"id,omitempty"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+	HTMLURL         string `json:"html_url,omitempty"`
+}
+
+type giteaTag struct {
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// NewGiteaReleaser creates a releaser for the Gitea or Forgejo instance at
+// baseURL, e.g. "https://codeberg.org"
+func NewGiteaReleaser(baseURL, token, owner, repo, branch string) (*giteaReleaser, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("gitea: base URL is required")
+	}
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v1")
+	g := &giteaReleaser{
+		client:  http.DefaultClient,
+		baseURL: baseURL + "/api/v1",
+		token:   token,
+		owner:   owner,
+		repo:    repo,
+		branch:  branch,
+	}
+	if g.branch == "" {
+		repository := &giteaRepository{}
+		err := g.do(context.TODO(), http.MethodGet, g.repoPath(), nil, repository)
+		if err != nil {
+			return nil, err
+		}
+		g.branch = repository.DefaultBranch
+	}
+	return g, nil
+}
+
+// Release creates the release of tag. Gitea has no latest flag, the latest
+// release is the newest one that is no draft or prerelease.
+func (g *giteaReleaser) Release(tag string, notes string, opts *semrel.ReleaseOptions) (string, error) {
+	if opts == nil {
+		opts = &semrel.ReleaseOptions{}
+	}
+	if len(opts.Assets) > 0 {
+		return "", errors.New("gitea: release assets are not supported")
+	}
+	ctx := context.TODO()
+	existing := &giteaRelease{}
+	err := g.do(ctx, http.MethodGet, g.repoPath()+"/releases/tags/"+url.PathEscape(tag), nil, existing)
+	if err != nil && !errors.Is(err, errGiteaNotFound) {
+		return "", err
+	}
+	if err == nil {
+		switch opts.OnExisting {
+		case semrel.OnExistingSkip:
+			return existing.HTMLURL, nil
+		case semrel.OnExistingUpdate:
+			rel := &giteaRelease{}
+			err := g.do(ctx, http.MethodPatch, g.repoPath()+"/releases/"+strconv.FormatInt(existing.ID, 10), map[string]string{"body": notes}, rel)
+			if err != nil {
+				return "", err
+			}
+			return rel.HTMLURL, nil
+		default:
+			return "", fmt.Errorf("%w: %s", ErrReleaseExists, tag)
+		}
+	}
+	rel := &giteaRelease{}
+	err = g.do(ctx, http.MethodPost, g.repoPath()+"/releases", &giteaRelease{
+		TagName:         tag,
+		TargetCommitish: target(opts, g.branch),
+		Name:            tag,
+		Body:            notes,
+		Draft:           opts.Draft,
+		Prerelease:      opts.Prerelease,
+	}, rel)
+	if err != nil {
+		return "", err
+	}
+	return rel.HTMLURL, g.verify(ctx, tag, opts)
+}
+
+// verify checks that the tag of a published release resolves to the target
+// commit. The tag of a draft is only created when it is published.
+func (g *giteaReleaser) verify(ctx context.Context, tag string, opts *semrel.ReleaseOptions) error {
+	if opts.Target == "" || opts.Draft {
+		return nil
+	}
+	t := &giteaTag{}
+	if err := g.do(ctx, http.MethodGet, g.repoPath()+"/tags/"+url.PathEscape(tag), nil, t); err != nil {
+		return fmt.Errorf("verifying tag %s: %w", tag, err)
+	}
+	return checkTarget(tag, t.Commit.SHA, opts)
+}
+
+func (g *giteaReleaser) repoPath() string {
+	return "/repos/" + url.PathEscape(g.owner) + "/" + url.PathEscape(g.repo)
+}
+
+// do sends in as JSON and decodes the response into out, if not nil
+func (g *giteaReleaser) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("gitea: %s %s: %w", method, path, errGiteaNotFound)
+		}
+		return fmt.Errorf("gitea: %s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,197 @@
+package release
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// giteaStub serves the repository and release endpoints of the Gitea API,
+// recording the created releases, which are then found by tag
+func giteaStub(t *testing.T, created *[]giteaRelease) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(giteaRepository{DefaultBranch: "trunk"})
+	})
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"unauthorized"}`))
+			return
+		}
+		rel := giteaRelease{}
+		if err := json.NewDecoder(r.Body).Decode(&rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		*created = append(*created, rel)
+		rel.ID = int64(len(*created))
+		rel.HTMLURL = "https://gitea.example.com/owner/repo/releases/tag/" + rel.TagName
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/releases/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		for i, rel := range *created {
+			if rel.TagName == r.PathValue("tag") {
+				rel.ID = int64(i + 1)
+				rel.HTMLURL = "https://gitea.example.com/owner/repo/releases/tag/" + rel.TagName
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"release not found"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGiteaRelease(t *testing.T) {
+	created := []giteaRelease{}
+	srv := giteaStub(t, &created)
+
+	r, err := Platform("forgejo", srv.URL+"/", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := r.Release("v1.2.0", "- feat: new feature\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitea.example.com/owner/repo/releases/tag/v1.2.0" {
+		t.Errorf("unexpected release URL %q", url)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected 1 release, got %d", len(created))
+	}
+	exp := giteaRelease{
+		TagName:         "v1.2.0",
+		TargetCommitish: "trunk",
+		Name:            "v1.2.0",
+		Body:            "- feat: new feature\n",
+	}
+	if created[0] != exp {
+		t.Errorf("expected %+v, got %+v", exp, created[0])
+	}
+}
+
+func TestGiteaReleaseError(t *testing.T) {
+	created := []giteaRelease{}
+	srv := giteaStub(t, &created)
+
+	r, err := NewGiteaReleaser(srv.URL+"/api/v1", "wrong", "owner", "repo", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "", nil); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestDetectPlatformGitea(t *testing.T) {
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("FORGEJO_ACTIONS", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITEA_ACTIONS", "true")
+	t.Setenv("GITHUB_SERVER_URL", "https://gitea.example.com")
+	t.Setenv("GITEA_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "secret")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+
+	platform, url, token, project, err := DetectPlatform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if platform != "gitea" || url != "https://gitea.example.com" || token != "secret" || project != "owner/repo" {
+		t.Errorf("unexpected detection: %s %s %s %s", platform, url, token, project)
+	}
+}
+
+func TestGiteaPrerelease(t *testing.T) {
+	created := []giteaRelease{}
+	srv := giteaStub(t, &created)
+	r, err := Platform("gitea", srv.URL, "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0-rc.1", "", &semrel.ReleaseOptions{Draft: true, Prerelease: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !created[0].Draft || !created[0].Prerelease {
+		t.Errorf("expected a draft prerelease, got %+v", created[0])
+	}
+}
+
+func TestGiteaReleaseOnExisting(t *testing.T) {
+	created := []giteaRelease{}
+	srv := giteaStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	updated := map[string]string{}
+	mux.HandleFunc("PATCH /api/v1/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rel := giteaRelease{}
+		if err := json.NewDecoder(r.Body).Decode(&rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		updated[r.PathValue("id")] = rel.Body
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+
+	r, err := Platform("gitea", srv.URL, "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "old notes", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "new notes", nil); !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("expected ErrReleaseExists, got %v", err)
+	}
+	url, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitea.example.com/owner/repo/releases/tag/v1.2.0" || len(updated) != 0 {
+		t.Errorf("expected the release to be skipped, got %q, %v updated", url, updated)
+	}
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingUpdate}); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || updated["1"] != "new notes" {
+		t.Errorf("expected the notes to be updated, got %d created, %v updated", len(created), updated)
+	}
+}
+
+func TestGiteaReleaseTarget(t *testing.T) {
+	created := []giteaRelease{}
+	srv := giteaStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	// v1.1.0 already exists at def
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		tag := giteaTag{}
+		tag.Commit.SHA = "abc"
+		if r.PathValue("tag") == "v1.1.0" {
+			tag.Commit.SHA = "def"
+		}
+		_ = json.NewEncoder(w).Encode(tag)
+	})
+
+	r, err := Platform("gitea", srv.URL, "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "", &semrel.ReleaseOptions{Target: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if created[0].TargetCommitish != "abc" {
+		t.Errorf("expected the release at abc, got %s", created[0].TargetCommitish)
+	}
+	if _, err := r.Release("v1.1.0", "", &semrel.ReleaseOptions{Target: "abc"}); !errors.Is(err, ErrTagMismatch) {
+		t.Errorf("expected ErrTagMismatch, got %v", err)
+	}
+}
@@ -2,8 +2,14 @@ package release
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/greatliontech/semrel/pkg/semrel"
 )
 
 var _ Releaser = (*githubReleaser)(nil)
@@ -15,8 +21,22 @@ type githubReleaser struct {
 	branch string
 }
 
-func NewGithubReleaser(token, owner, repo, branch string) (*githubReleaser, error) {
+// NewGithubReleaser creates a releaser for github.com, or for the GitHub
+// Enterprise Server API at baseURL, e.g. "https://ghe.example.com/api/v3"
+func NewGithubReleaser(baseURL, token, owner, repo, branch string) (*githubReleaser, error) {
 	client := github.NewClient(nil).WithAuthToken(token)
+	if baseURL != "" && strings.TrimSuffix(baseURL, "/") != "https://api.github.com" {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		uploadURL := baseURL
+		if strings.HasSuffix(baseURL, "/api/v3") {
+			uploadURL = strings.TrimSuffix(baseURL, "/api/v3") + "/api/uploads"
+		}
+		var err error
+		client, err = client.WithEnterpriseURLs(baseURL, uploadURL)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if branch == "" {
 		repository, _, err := client.Repositories.Get(context.TODO(), owner, repo)
 		if err != nil {
@@ -32,12 +52,161 @@ func NewGithubReleaser(token, owner, repo, branch string) (*githubReleaser, erro
 	}, nil
 }
 
-func (g *githubReleaser) Release(tag string, notes string) error {
-	_, _, err := g.client.Repositories.CreateRelease(context.TODO(), g.owner, g.repo, &github.RepositoryRelease{
+// Release creates the release of tag. With assets, it is a draft until they
+// are uploaded, and deleted if an upload fails.
+func (g *githubReleaser) Release(tag string, notes string, opts *semrel.ReleaseOptions) (string, error) {
+	if opts == nil {
+		opts = &semrel.ReleaseOptions{}
+	}
+	ctx := context.TODO()
+	existing, err := g.releaseByTag(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		switch opts.OnExisting {
+		case semrel.OnExistingSkip:
+			warnSkippedAssets(tag, opts)
+			return existing.GetHTMLURL(), nil
+		case semrel.OnExistingUpdate:
+			rel, _, err := g.client.Repositories.EditRelease(ctx, g.owner, g.repo, existing.GetID(), &github.RepositoryRelease{
+				Body: github.Ptr(notes),
+			})
+			if err != nil {
+				return "", err
+			}
+			// the assets it has are kept, the release is not rolled back
+			uploaded := map[string]bool{}
+			for _, a := range existing.Assets {
+				uploaded[a.GetName()] = true
+			}
+			for _, a := range opts.Assets {
+				if uploaded[assetName(a)] {
+					continue
+				}
+				if err := g.upload(ctx, existing.GetID(), a); err != nil {
+					return "", err
+				}
+			}
+			return rel.GetHTMLURL(), nil
+		default:
+			return "", fmt.Errorf("%w: %s", ErrReleaseExists, tag)
+		}
+	}
+	rel, _, err := g.client.Repositories.CreateRelease(ctx, g.owner, g.repo, &github.RepositoryRelease{
 		TagName:         github.Ptr(tag),
-		TargetCommitish: github.Ptr(g.branch),
+		TargetCommitish: github.Ptr(target(opts, g.branch)),
 		Name:            github.Ptr(tag),
 		Body:            github.Ptr(notes),
+		Draft:           github.Ptr(opts.Draft || len(opts.Assets) > 0),
+		Prerelease:      github.Ptr(opts.Prerelease),
+		MakeLatest:      githubMakeLatest(opts),
 	})
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Assets) == 0 {
+		return rel.GetHTMLURL(), g.verify(ctx, tag, opts)
+	}
+	id := rel.GetID()
+	for _, a := range opts.Assets {
+		if err := g.upload(ctx, id, a); err != nil {
+			return "", g.rollback(ctx, id, err)
+		}
+	}
+	if opts.Draft {
+		return rel.GetHTMLURL(), nil
+	}
+	rel, _, err = g.client.Repositories.EditRelease(ctx, g.owner, g.repo, id, &github.RepositoryRelease{
+		Draft:      github.Ptr(false),
+		MakeLatest: githubMakeLatest(opts),
+	})
+	if err != nil {
+		return "", g.rollback(ctx, id, fmt.Errorf("publishing release: %w", err))
+	}
+	return rel.GetHTMLURL(), g.verify(ctx, tag, opts)
+}
+
+// verify checks that the tag of a published release resolves to the target
+// commit. The tag of a draft is only created when it is published.
+func (g *githubReleaser) verify(ctx context.Context, tag string, opts *semrel.ReleaseOptions) error {
+	if opts.Target == "" || opts.Draft {
+		return nil
+	}
+	ref, _, err := g.client.Git.GetRef(ctx, g.owner, g.repo, "tags/"+tag)
+	if err != nil {
+		return fmt.Errorf("verifying tag %s: %w", tag, err)
+	}
+	obj := ref.GetObject()
+	// peel annotated tags
+	for obj.GetType() == "tag" {
+		t, _, err := g.client.Git.GetTag(ctx, g.owner, g.repo, obj.GetSHA())
+		if err != nil {
+			return fmt.Errorf("verifying tag %s: %w", tag, err)
+		}
+		obj = t.GetObject()
+	}
+	return checkTarget(tag, obj.GetSHA(), opts)
+}
+
+// githubMakeLatest is the make_latest value of opts. Prereleases cannot be
+// the latest release.
+func githubMakeLatest(opts *semrel.ReleaseOptions) *string {
+	switch {
+	case opts.Prerelease:
+		return github.Ptr("false")
+	case opts.Latest != nil:
+		return github.Ptr(strconv.FormatBool(*opts.Latest))
+	default:
+		return nil
+	}
+}
+
+// releaseByTag returns the release of tag, or nil if there is none. Drafts
+// are not found by tag, so they are looked up in the list of releases.
+func (g *githubReleaser) releaseByTag(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	rel, resp, err := g.client.Repositories.GetReleaseByTag(ctx, g.owner, g.repo, tag)
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return rel, err
+	}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		rels, resp, err := g.client.Repositories.ListReleases(ctx, g.owner, g.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range rels {
+			if rel.GetTagName() == tag {
+				return rel, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (g *githubReleaser) upload(ctx context.Context, id int64, a semrel.Asset) error {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = g.client.Repositories.UploadReleaseAsset(ctx, g.owner, g.repo, id, &github.UploadOptions{
+		Name:  assetName(a),
+		Label: a.Label,
+	}, f)
+	if err != nil {
+		return fmt.Errorf("uploading asset %s: %w", assetName(a), err)
+	}
+	return nil
+}
+
+// rollback deletes the draft release id after err
+func (g *githubReleaser) rollback(ctx context.Context, id int64, err error) error {
+	if _, derr := g.client.Repositories.DeleteRelease(ctx, g.owner, g.repo, id); derr != nil {
+		return fmt.Errorf("%w, and the draft release could not be deleted: %v", err, derr)
+	}
 	return err
 }
@@ -0,0 +1,340 @@
+package release
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// githubStub serves the repository and release endpoints of the GitHub
+// Enterprise Server API, recording the created releases, which are then
+// listed, and found by tag unless drafts
+func githubStub(t *testing.T, created *[]*github.RepositoryRelease) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&github.Repository{DefaultBranch: github.Ptr("trunk")})
+	})
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("expected token auth, got %q", got)
+		}
+		rel := &github.RepositoryRelease{}
+		if err := json.NewDecoder(r.Body).Decode(rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		*created = append(*created, rel)
+		rel.ID = github.Ptr(int64(len(*created)))
+		rel.HTMLURL = github.Ptr("https://ghe.example.com/owner/repo/releases/tag/" + rel.GetTagName())
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+	// drafts are not found by tag
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/releases/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		for _, rel := range *created {
+			if rel.GetTagName() == r.PathValue("tag") && !rel.GetDraft() {
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(*created)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGithubEnterpriseRelease(t *testing.T) {
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := r.Release("v1.2.0", "- feat: new feature\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://ghe.example.com/owner/repo/releases/tag/v1.2.0" {
+		t.Errorf("unexpected release URL %q", url)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected 1 release, got %d", len(created))
+	}
+	rel := created[0]
+	if rel.GetTagName() != "v1.2.0" || rel.GetTargetCommitish() != "trunk" || rel.GetBody() != "- feat: new feature\n" {
+		t.Errorf("unexpected release: %+v", rel)
+	}
+}
+
+func TestGithubReleaseAssets(t *testing.T) {
+	dir := writeAssets(t, map[string]string{"app.tar.gz": "app", "broken.tar.gz": "broken"})
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	uploaded := []string{}
+	published, deleted := false, false
+	mux.HandleFunc("POST /api/uploads/repos/owner/repo/releases/{id}/assets", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "broken.tar.gz" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		uploaded = append(uploaded, name+"#"+r.URL.Query().Get("label"))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&github.ReleaseAsset{Name: github.Ptr(name)})
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rel := &github.RepositoryRelease{}
+		if err := json.NewDecoder(r.Body).Decode(rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		published = !rel.GetDraft()
+		rel.HTMLURL = github.Ptr("https://ghe.example.com/owner/repo/releases/tag/v1.2.0")
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+	mux.HandleFunc("DELETE /api/v3/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := r.Release("v1.2.0", "", &semrel.ReleaseOptions{
+		Assets: []semrel.Asset{{Path: filepath.Join(dir, "app.tar.gz"), Label: "App"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://ghe.example.com/owner/repo/releases/tag/v1.2.0" {
+		t.Errorf("unexpected release URL %q", url)
+	}
+	if !created[0].GetDraft() || !published {
+		t.Error("expected a draft release published after the upload")
+	}
+	if len(uploaded) != 1 || uploaded[0] != "app.tar.gz#App" {
+		t.Errorf("unexpected uploads %v", uploaded)
+	}
+	if deleted {
+		t.Error("expected the release to be kept")
+	}
+
+	// a failed upload deletes the draft
+	published = false
+	_, err = r.Release("v1.3.0", "", &semrel.ReleaseOptions{
+		Assets: []semrel.Asset{
+			{Path: filepath.Join(dir, "app.tar.gz")},
+			{Path: filepath.Join(dir, "broken.tar.gz")},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken.tar.gz") {
+		t.Errorf("expected an error naming the asset, got %v", err)
+	}
+	if !deleted || published {
+		t.Error("expected the draft release to be deleted")
+	}
+}
+
+func TestGithubReleaseOptions(t *testing.T) {
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := false
+	tests := []struct {
+		name       string
+		opts       *semrel.ReleaseOptions
+		draft      bool
+		prerelease bool
+		makeLatest string
+	}{
+		{"default", nil, false, false, ""},
+		{"draft", &semrel.ReleaseOptions{Draft: true}, true, false, ""},
+		{"prerelease", &semrel.ReleaseOptions{Prerelease: true}, false, true, "false"},
+		{"not latest", &semrel.ReleaseOptions{Latest: &latest}, false, false, "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created = created[:0]
+			if _, err := r.Release("v1.2.0", "", tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			rel := created[0]
+			if rel.GetDraft() != tt.draft || rel.GetPrerelease() != tt.prerelease || rel.GetMakeLatest() != tt.makeLatest {
+				t.Errorf("expected draft %t, prerelease %t, make_latest %q, got %t, %t, %q", tt.draft, tt.prerelease, tt.makeLatest, rel.GetDraft(), rel.GetPrerelease(), rel.GetMakeLatest())
+			}
+		})
+	}
+}
+
+func TestGithubReleaseOnExisting(t *testing.T) {
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	edited := map[string]string{}
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rel := &github.RepositoryRelease{}
+		if err := json.NewDecoder(r.Body).Decode(rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		edited[r.PathValue("id")] = rel.GetBody()
+		rel.HTMLURL = github.Ptr("https://ghe.example.com/owner/repo/releases/tag/v1.2.0")
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "old notes", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Release("v1.2.0", "new notes", nil)
+	if !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("expected ErrReleaseExists, got %v", err)
+	}
+
+	url, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://ghe.example.com/owner/repo/releases/tag/v1.2.0" || len(created) != 1 || len(edited) != 0 {
+		t.Errorf("expected the release to be skipped, got %q, %d created, %v edited", url, len(created), edited)
+	}
+
+	url, err = r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingUpdate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://ghe.example.com/owner/repo/releases/tag/v1.2.0" || len(created) != 1 || edited["1"] != "new notes" {
+		t.Errorf("expected the notes to be updated, got %q, %d created, %v edited", url, len(created), edited)
+	}
+}
+
+func TestGithubReleaseOnExistingAssets(t *testing.T) {
+	dir := writeAssets(t, map[string]string{"app.tar.gz": "app", "SHA256SUMS": "sums"})
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	uploaded := []string{}
+	mux.HandleFunc("POST /api/uploads/repos/owner/repo/releases/{id}/assets", func(w http.ResponseWriter, r *http.Request) {
+		uploaded = append(uploaded, r.PathValue("id")+"/"+r.URL.Query().Get("name"))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&github.ReleaseAsset{Name: github.Ptr(r.URL.Query().Get("name"))})
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&github.RepositoryRelease{HTMLURL: github.Ptr("https://ghe.example.com/owner/repo/releases/tag/v1.2.0")})
+	})
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "old notes", nil); err != nil {
+		t.Fatal(err)
+	}
+	created[0].Assets = []*github.ReleaseAsset{{Name: github.Ptr("app.tar.gz")}}
+	assets := []semrel.Asset{{Path: filepath.Join(dir, "app.tar.gz")}, {Path: filepath.Join(dir, "SHA256SUMS")}}
+
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Assets: assets, OnExisting: semrel.OnExistingSkip}); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 0 {
+		t.Errorf("expected no uploads to the skipped release, got %v", uploaded)
+	}
+
+	// only the missing assets are uploaded
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Assets: assets, OnExisting: semrel.OnExistingUpdate}); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "1/SHA256SUMS" {
+		t.Errorf("expected SHA256SUMS uploaded to release 1, got %v", uploaded)
+	}
+}
+
+func TestGithubDraftReleaseOnExisting(t *testing.T) {
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	edited := map[string]string{}
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/releases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rel := &github.RepositoryRelease{}
+		if err := json.NewDecoder(r.Body).Decode(rel); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		edited[r.PathValue("id")] = rel.GetBody()
+		rel.HTMLURL = github.Ptr("https://ghe.example.com/owner/repo/releases/tag/v1.2.0")
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "old notes", &semrel.ReleaseOptions{Draft: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Draft: true}); !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("expected ErrReleaseExists, got %v", err)
+	}
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Draft: true, OnExisting: semrel.OnExistingSkip}); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || len(edited) != 0 {
+		t.Errorf("expected the draft to be skipped, got %d created, %v edited", len(created), edited)
+	}
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Draft: true, OnExisting: semrel.OnExistingUpdate}); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || edited["1"] != "new notes" {
+		t.Errorf("expected the draft notes to be updated, got %d created, %v edited", len(created), edited)
+	}
+}
+
+func TestGithubReleaseTarget(t *testing.T) {
+	created := []*github.RepositoryRelease{}
+	srv := githubStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	// v1.2.0 is an annotated tag of abc, v1.1.0 a lightweight tag of def
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/git/ref/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		obj := &github.GitObject{Type: github.Ptr("tag"), SHA: github.Ptr("t1")}
+		if r.PathValue("tag") == "v1.1.0" {
+			obj = &github.GitObject{Type: github.Ptr("commit"), SHA: github.Ptr("def")}
+		}
+		_ = json.NewEncoder(w).Encode(&github.Reference{Object: obj})
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/git/tags/t1", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&github.Tag{Object: &github.GitObject{Type: github.Ptr("commit"), SHA: github.Ptr("abc")}})
+	})
+
+	r, err := Platform("github", srv.URL+"/api/v3", "secret", "owner/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "", &semrel.ReleaseOptions{Target: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if created[0].GetTargetCommitish() != "abc" {
+		t.Errorf("expected the release at abc, got %s", created[0].GetTargetCommitish())
+	}
+	if _, err := r.Release("v1.1.0", "", &semrel.ReleaseOptions{Target: "abc"}); !errors.Is(err, ErrTagMismatch) {
+		t.Errorf("expected ErrTagMismatch, got %v", err)
+	}
+}
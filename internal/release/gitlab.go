@@ -1,6 +1,14 @@
 package release
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
@@ -12,8 +20,14 @@ type gitlabReleaser struct {
 	branch    string
 }
 
-func NewGitlabReleaser(token, projectID, branch string) (*gitlabReleaser, error) {
-	client, err := gitlab.NewClient(token)
+// NewGitlabReleaser creates a releaser for gitlab.com, or for the self-managed
+// GitLab API at baseURL, e.g. "https://gitlab.example.com/api/v4"
+func NewGitlabReleaser(baseURL, token, projectID, branch string) (*gitlabReleaser, error) {
+	opts := []gitlab.ClientOptionFunc{}
+	if baseURL != "" {
+		opts = append(opts, gitlab.WithBaseURL(baseURL))
+	}
+	client, err := gitlab.NewClient(token, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,11 +45,152 @@ func NewGitlabReleaser(token, projectID, branch string) (*gitlabReleaser, error)
 	}, nil
 }
 
-func (r *gitlabReleaser) Release(tag, notes string) error {
-	_, _, err := r.client.Releases.CreateRelease(r.projectID, &gitlab.CreateReleaseOptions{
+// gitlabPackageName is the generic package the assets are uploaded to, with
+// the tag as version
+const gitlabPackageName = "release"
+
+// Release creates the release of tag, linking the assets uploaded to the
+// generic package registry. The package is deleted if anything fails. GitLab
+// has no drafts, so they are created as upcoming releases, released a year
+// from now until edited. It has no prerelease or latest flags either, the
+// latest release is the one released last.
+func (r *gitlabReleaser) Release(tag, notes string, opts *semrel.ReleaseOptions) (string, error) {
+	if opts == nil {
+		opts = &semrel.ReleaseOptions{}
+	}
+	existing, _, err := r.client.Releases.GetRelease(r.projectID, tag)
+	if err != nil && !errors.Is(err, gitlab.ErrNotFound) {
+		return "", err
+	}
+	if err == nil {
+		switch opts.OnExisting {
+		case semrel.OnExistingSkip:
+			warnSkippedAssets(tag, opts)
+			return existing.Links.Self, nil
+		case semrel.OnExistingUpdate:
+			rel, _, err := r.client.Releases.UpdateRelease(r.projectID, tag, &gitlab.UpdateReleaseOptions{
+				Name:        gitlab.Ptr(existing.Name),
+				Description: gitlab.Ptr(notes),
+			})
+			if err != nil {
+				return "", err
+			}
+			return rel.Links.Self, r.linkMissing(tag, existing, opts.Assets)
+		default:
+			return "", fmt.Errorf("%w: %s", ErrReleaseExists, tag)
+		}
+	}
+	create := &gitlab.CreateReleaseOptions{
 		TagName:     gitlab.Ptr(tag),
-		Ref:         gitlab.Ptr(r.branch),
+		Ref:         gitlab.Ptr(target(opts, r.branch)),
 		Description: gitlab.Ptr(notes),
+	}
+	if opts.Draft {
+		create.ReleasedAt = gitlab.Ptr(time.Now().AddDate(1, 0, 0))
+	}
+	packageID := 0
+	if len(opts.Assets) > 0 {
+		create.Assets = &gitlab.ReleaseAssetsOptions{}
+		version := strings.ReplaceAll(tag, "/", "-")
+		for _, a := range opts.Assets {
+			link, id, err := r.upload(version, a)
+			if id != 0 {
+				packageID = id
+			}
+			if err != nil {
+				return "", r.rollback(packageID, err)
+			}
+			create.Assets.Links = append(create.Assets.Links, link)
+		}
+	}
+	rel, _, err := r.client.Releases.CreateRelease(r.projectID, create)
+	if err != nil {
+		return "", r.rollback(packageID, err)
+	}
+	return rel.Links.Self, r.verify(tag, opts)
+}
+
+// linkMissing uploads and links the assets the existing release of tag has no
+// link of. The package is not rolled back, it holds the linked assets too.
+func (r *gitlabReleaser) linkMissing(tag string, existing *gitlab.Release, assets []semrel.Asset) error {
+	linked := map[string]bool{}
+	for _, l := range existing.Assets.Links {
+		linked[l.Name] = true
+	}
+	version := strings.ReplaceAll(tag, "/", "-")
+	for _, a := range assets {
+		if linked[gitlabLinkName(a)] {
+			continue
+		}
+		link, _, err := r.upload(version, a)
+		if err != nil {
+			return err
+		}
+		_, _, err = r.client.ReleaseLinks.CreateReleaseLink(r.projectID, tag, &gitlab.CreateReleaseLinkOptions{
+			Name:     link.Name,
+			URL:      link.URL,
+			LinkType: link.LinkType,
+		})
+		if err != nil {
+			return fmt.Errorf("linking asset %s: %w", assetName(a), err)
+		}
+	}
+	return nil
+}
+
+// verify checks that the tag of the release resolves to the target commit
+func (r *gitlabReleaser) verify(tag string, opts *semrel.ReleaseOptions) error {
+	if opts.Target == "" {
+		return nil
+	}
+	t, _, err := r.client.Tags.GetTag(r.projectID, tag)
+	if err != nil {
+		return fmt.Errorf("verifying tag %s: %w", tag, err)
+	}
+	if t.Commit == nil {
+		return fmt.Errorf("verifying tag %s: no commit", tag)
+	}
+	return checkTarget(tag, t.Commit.ID, opts)
+}
+
+// upload publishes a to the generic package and returns its release link
+// and the package ID
+func (r *gitlabReleaser) upload(version string, a semrel.Asset) (*gitlab.ReleaseAssetLinkOptions, int, error) {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	name := assetName(a)
+	file, _, err := r.client.GenericPackages.PublishPackageFile(r.projectID, gitlabPackageName, version, name, f, &gitlab.PublishPackageFileOptions{
+		Select: gitlab.Ptr(gitlab.SelectPackageFile),
 	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("uploading asset %s: %w", name, err)
+	}
+	path := fmt.Sprintf("projects/%s/packages/generic/%s/%s/%s", url.PathEscape(r.projectID), gitlabPackageName, url.PathEscape(version), url.PathEscape(name))
+	return &gitlab.ReleaseAssetLinkOptions{
+		Name:     gitlab.Ptr(gitlabLinkName(a)),
+		URL:      gitlab.Ptr(r.client.BaseURL().String() + path),
+		LinkType: gitlab.Ptr(gitlab.PackageLinkType),
+	}, file.PackageID, nil
+}
+
+// gitlabLinkName is the name of the release link of a, its label if set
+func gitlabLinkName(a semrel.Asset) string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return assetName(a)
+}
+
+// rollback deletes the package after err, if any file was uploaded
+func (r *gitlabReleaser) rollback(packageID int, err error) error {
+	if packageID == 0 {
+		return err
+	}
+	if _, derr := r.client.Packages.DeleteProjectPackage(r.projectID, packageID); derr != nil {
+		return fmt.Errorf("%w, and the uploaded package could not be deleted: %v", err, derr)
+	}
 	return err
 }
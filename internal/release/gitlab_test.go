@@ -0,0 +1,287 @@
+package release
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitlabStub serves the project and release endpoints of the GitLab API,
+// recording the created releases, which are then found by tag
+func gitlabStub(t *testing.T, created *[]*gitlab.CreateReleaseOptions) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/123", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&gitlab.Project{ID: 123, DefaultBranch: "trunk"})
+	})
+	mux.HandleFunc("POST /api/v4/projects/123/releases", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Private-Token"); got != "secret" {
+			t.Errorf("expected token auth, got %q", got)
+		}
+		opts := &gitlab.CreateReleaseOptions{}
+		if err := json.NewDecoder(r.Body).Decode(opts); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		*created = append(*created, opts)
+		w.WriteHeader(http.StatusCreated)
+		rel := &gitlab.Release{TagName: *opts.TagName}
+		rel.Links.Self = "https://gitlab.example.com/group/project/-/releases/" + rel.TagName
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+	mux.HandleFunc("GET /api/v4/projects/123/releases/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		for _, opts := range *created {
+			if *opts.TagName == r.PathValue("tag") {
+				rel := &gitlab.Release{TagName: *opts.TagName, Name: *opts.TagName, Description: *opts.Description}
+				if opts.Assets != nil {
+					for _, l := range opts.Assets.Links {
+						rel.Assets.Links = append(rel.Assets.Links, &gitlab.ReleaseLink{Name: *l.Name, URL: *l.URL})
+					}
+				}
+				rel.Links.Self = "https://gitlab.example.com/group/project/-/releases/" + rel.TagName
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Not Found"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitlabSelfManagedRelease(t *testing.T) {
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := r.Release("v1.2.0", "- feat: new feature\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitlab.example.com/group/project/-/releases/v1.2.0" {
+		t.Errorf("unexpected release URL %q", url)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected 1 release, got %d", len(created))
+	}
+	opts := created[0]
+	if *opts.TagName != "v1.2.0" || *opts.Ref != "trunk" || *opts.Description != "- feat: new feature\n" {
+		t.Errorf("unexpected release: %+v", opts)
+	}
+}
+
+func TestGitlabReleaseAssets(t *testing.T) {
+	dir := writeAssets(t, map[string]string{"app.tar.gz": "app", "broken.tar.gz": "broken"})
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	uploaded := []string{}
+	deleted := ""
+	mux.HandleFunc("PUT /api/v4/projects/123/packages/generic/release/{version}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("file") == "broken.tar.gz" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		uploaded = append(uploaded, r.PathValue("version")+"/"+r.PathValue("file"))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&gitlab.GenericPackagesFile{PackageID: 7, FileName: r.PathValue("file")})
+	})
+	mux.HandleFunc("DELETE /api/v4/projects/123/packages/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = r.PathValue("id")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Release("api/v1.2.0", "", &semrel.ReleaseOptions{
+		Assets: []semrel.Asset{{Path: filepath.Join(dir, "app.tar.gz"), Label: "App"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "api-v1.2.0/app.tar.gz" {
+		t.Errorf("unexpected uploads %v", uploaded)
+	}
+	links := created[0].Assets.Links
+	if len(links) != 1 || *links[0].Name != "App" || *links[0].URL != srv.URL+"/api/v4/projects/123/packages/generic/release/api-v1.2.0/app.tar.gz" {
+		t.Errorf("unexpected links %+v", links[0])
+	}
+	if deleted != "" {
+		t.Error("expected the package to be kept")
+	}
+
+	// a failed upload deletes the package and creates no release
+	_, err = r.Release("v1.3.0", "", &semrel.ReleaseOptions{
+		Assets: []semrel.Asset{
+			{Path: filepath.Join(dir, "app.tar.gz")},
+			{Path: filepath.Join(dir, "broken.tar.gz")},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken.tar.gz") {
+		t.Errorf("expected an error naming the asset, got %v", err)
+	}
+	if deleted != "7" {
+		t.Errorf("expected package 7 to be deleted, got %q", deleted)
+	}
+	if len(created) != 1 {
+		t.Errorf("expected no release, got %d", len(created))
+	}
+}
+
+func TestGitlabDraftRelease(t *testing.T) {
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "", &semrel.ReleaseOptions{Draft: true}); err != nil {
+		t.Fatal(err)
+	}
+	if created[0].ReleasedAt == nil || !created[0].ReleasedAt.After(time.Now()) {
+		t.Errorf("expected an upcoming release, got %v", created[0].ReleasedAt)
+	}
+}
+
+func TestGitlabReleaseOnExisting(t *testing.T) {
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	updated := map[string]string{}
+	mux.HandleFunc("PUT /api/v4/projects/123/releases/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		opts := &gitlab.UpdateReleaseOptions{}
+		if err := json.NewDecoder(r.Body).Decode(opts); err != nil {
+			t.Errorf("could not decode release: %v", err)
+		}
+		updated[r.PathValue("tag")] = *opts.Description
+		rel := &gitlab.Release{TagName: r.PathValue("tag")}
+		rel.Links.Self = "https://gitlab.example.com/group/project/-/releases/" + rel.TagName
+		_ = json.NewEncoder(w).Encode(rel)
+	})
+
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "old notes", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Release("v1.2.0", "new notes", nil)
+	if !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("expected ErrReleaseExists, got %v", err)
+	}
+
+	url, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitlab.example.com/group/project/-/releases/v1.2.0" || len(created) != 1 || len(updated) != 0 {
+		t.Errorf("expected the release to be skipped, got %q, %d created, %v updated", url, len(created), updated)
+	}
+
+	url, err = r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{OnExisting: semrel.OnExistingUpdate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitlab.example.com/group/project/-/releases/v1.2.0" || len(created) != 1 || updated["v1.2.0"] != "new notes" {
+		t.Errorf("expected the notes to be updated, got %q, %d created, %v updated", url, len(created), updated)
+	}
+}
+
+func TestGitlabReleaseOnExistingAssets(t *testing.T) {
+	dir := writeAssets(t, map[string]string{"app.tar.gz": "app", "SHA256SUMS": "sums"})
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	uploaded, linked := []string{}, []string{}
+	mux.HandleFunc("PUT /api/v4/projects/123/packages/generic/release/{version}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		uploaded = append(uploaded, r.PathValue("version")+"/"+r.PathValue("file"))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&gitlab.GenericPackagesFile{PackageID: 7, FileName: r.PathValue("file")})
+	})
+	mux.HandleFunc("PUT /api/v4/projects/123/releases/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&gitlab.Release{TagName: r.PathValue("tag")})
+	})
+	mux.HandleFunc("POST /api/v4/projects/123/releases/{tag}/assets/links", func(w http.ResponseWriter, r *http.Request) {
+		opts := &gitlab.CreateReleaseLinkOptions{}
+		if err := json.NewDecoder(r.Body).Decode(opts); err != nil {
+			t.Errorf("could not decode link: %v", err)
+		}
+		linked = append(linked, r.PathValue("tag")+"/"+*opts.Name)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&gitlab.ReleaseLink{Name: *opts.Name, URL: *opts.URL})
+	})
+
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := []semrel.Asset{{Path: filepath.Join(dir, "app.tar.gz"), Label: "App"}}
+	if _, err := r.Release("v1.2.0", "old notes", &semrel.ReleaseOptions{Assets: assets}); err != nil {
+		t.Fatal(err)
+	}
+	uploaded = uploaded[:0]
+	assets = append(assets, semrel.Asset{Path: filepath.Join(dir, "SHA256SUMS")})
+
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Assets: assets, OnExisting: semrel.OnExistingSkip}); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 0 || len(linked) != 0 {
+		t.Errorf("expected no uploads to the skipped release, got %v and %v", uploaded, linked)
+	}
+
+	// only the missing assets are uploaded and linked
+	if _, err := r.Release("v1.2.0", "new notes", &semrel.ReleaseOptions{Assets: assets, OnExisting: semrel.OnExistingUpdate}); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "v1.2.0/SHA256SUMS" {
+		t.Errorf("expected SHA256SUMS uploaded, got %v", uploaded)
+	}
+	if len(linked) != 1 || linked[0] != "v1.2.0/SHA256SUMS" {
+		t.Errorf("expected SHA256SUMS linked, got %v", linked)
+	}
+}
+
+func TestGitlabReleaseTarget(t *testing.T) {
+	created := []*gitlab.CreateReleaseOptions{}
+	srv := gitlabStub(t, &created)
+	mux := srv.Config.Handler.(*http.ServeMux)
+	// v1.1.0 already exists at def
+	mux.HandleFunc("GET /api/v4/projects/123/repository/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		commit := "abc"
+		if r.PathValue("tag") == "v1.1.0" {
+			commit = "def"
+		}
+		_ = json.NewEncoder(w).Encode(&gitlab.Tag{Name: r.PathValue("tag"), Commit: &gitlab.Commit{ID: commit}})
+	})
+
+	r, err := Platform("gitlab", srv.URL+"/api/v4", "secret", "123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Release("v1.2.0", "", &semrel.ReleaseOptions{Target: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if *created[0].Ref != "abc" {
+		t.Errorf("expected the release at abc, got %s", *created[0].Ref)
+	}
+	if _, err := r.Release("v1.1.0", "", &semrel.ReleaseOptions{Target: "abc"}); !errors.Is(err, ErrTagMismatch) {
+		t.Errorf("expected ErrTagMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package release
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
+)
+
+// Links are the URL templates of the commits and version comparisons in
+// release notes. A nil template renders no link.
+type Links struct {
+	// Commit is rendered with the commit
+	Commit *template.Template
+	// Compare is rendered with .Previous and .Tag
+	Compare *template.Template
+}
+
+type compareData struct {
+	Previous string
+	Tag      string
+}
+
+// NewLinks parses the commit and compare URL templates, either can be empty.
+// They are rendered once, so that invalid fields fail here.
+func NewLinks(commitURL, compareURL string) (*Links, error) {
+	l := &Links{}
+	if commitURL != "" {
+		tmpl, err := template.New("commitURL").Parse(commitURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit URL: %w", err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, &semrel.Commit{}); err != nil {
+			return nil, fmt.Errorf("invalid commit URL: %w", err)
+		}
+		l.Commit = tmpl
+	}
+	if compareURL != "" {
+		tmpl, err := template.New("compareURL").Parse(compareURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compare URL: %w", err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, compareData{}); err != nil {
+			return nil, fmt.Errorf("invalid compare URL: %w", err)
+		}
+		l.Compare = tmpl
+	}
+	return l, nil
+}
+
+// PlatformLinks returns the links of the repository at webURL on platform,
+// or nil if the platform is not known. If platform is empty, it is guessed
+// from the host of well-known instances.
+func PlatformLinks(platform, webURL string) *Links {
+	if platform == "" {
+		platform = hostPlatform(webURL)
+	}
+	base := strings.TrimSuffix(webURL, "/")
+	var commitURL, compareURL string
+	switch strings.ToLower(platform) {
+	case "github", "gitea", "forgejo":
+		commitURL = base + "/commit/{{ .Hash }}"
+		compareURL = base + "/compare/{{ .Previous }}...{{ .Tag }}"
+	case "gitlab":
+		commitURL = base + "/-/commit/{{ .Hash }}"
+		compareURL = base + "/-/compare/{{ .Previous }}...{{ .Tag }}"
+	default:
+		return nil
+	}
+	return &Links{
+		Commit:  template.Must(template.New("commitURL").Parse(commitURL)),
+		Compare: template.Must(template.New("compareURL").Parse(compareURL)),
+	}
+}
+
+// hostPlatform returns the platform of well-known hosts
+func hostPlatform(webURL string) string {
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return ""
+	}
+	switch u.Hostname() {
+	case "github.com":
+		return "github"
+	case "gitlab.com":
+		return "gitlab"
+	case "codeberg.org":
+		return "forgejo"
+	default:
+		return ""
+	}
+}
+
+// CommitURL returns the link of c, empty if there is no template or c has no
+// hash
+func (l *Links) CommitURL(c *semrel.Commit) string {
+	if l == nil || l.Commit == nil || c.Hash == "" {
+		return ""
+	}
+	return execute(l.Commit, c)
+}
+
+// CompareURL returns the link between the previous and the given tag, empty
+// if there is no template or no previous tag
+func (l *Links) CompareURL(previous, tag string) string {
+	if l == nil || l.Compare == nil || previous == "" {
+		return ""
+	}
+	return execute(l.Compare, compareData{Previous: previous, Tag: tag})
+}
+
+// execute renders tmpl, which NewLinks has checked, or returns empty
+func execute(tmpl *template.Template, data any) string {
+	b := strings.Builder{}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// WebURL returns the web URL of the repository at the git remote URL, for
+// HTTP(S) and SSH remotes. Credentials, ports of SSH remotes and the .git
+// suffix are removed.
+func WebURL(remote string) (string, error) {
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax, e.g. git@github.com:owner/repo.git
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok {
+			return "", fmt.Errorf("unsupported remote URL %q", remote)
+		}
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+		remote = "ssh://" + host + "/" + strings.TrimPrefix(path, "/")
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return "", err
+	}
+	host := u.Host
+	switch u.Scheme {
+	case "http", "https":
+	case "ssh", "git":
+		host = u.Hostname()
+		u.Scheme = "https"
+	default:
+		return "", fmt.Errorf("unsupported remote URL %q", remote)
+	}
+	path := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", fmt.Errorf("unsupported remote URL %q", remote)
+	}
+	return u.Scheme + "://" + host + path, nil
+}
@@ -1,8 +1,11 @@
 package release
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/greatliontech/semrel/pkg/semrel"
 )
@@ -55,7 +58,66 @@ func (f *Filters) MatchScope(s string) bool {
 	return false
 }
 
-func GenerateReleaseNotes(commits []*semrel.Commit, filters *Filters, matchRules []*MatchRule) string {
+// NotesOptions link the notes to the platform and credit the authors, all
+// optional
+type NotesOptions struct {
+	// Links are the URL templates of the commits and the comparison
+	Links *Links
+	// Previous and Tag are the compared tags, there is no comparison without
+	// a previous tag
+	Previous string
+	Tag      string
+	// Contributors lists the authors of the commits
+	Contributors bool
+}
+
+// compareURL is the link between the tags of opts, if any
+func (o *NotesOptions) compareURL() string {
+	if o == nil {
+		return ""
+	}
+	return o.Links.CompareURL(o.Previous, o.Tag)
+}
+
+// commitURL is the link of c, if any
+func (o *NotesOptions) commitURL(c *semrel.Commit) string {
+	if o == nil {
+		return ""
+	}
+	return o.Links.CommitURL(c)
+}
+
+// contributors returns the authors of commits, once per email, sorted by
+// name, if opts lists them
+func (o *NotesOptions) contributors(commits []*semrel.Commit) []semrel.Author {
+	if o == nil || !o.Contributors {
+		return nil
+	}
+	authors := []semrel.Author{}
+	seen := map[string]bool{}
+	for _, c := range commits {
+		if c.Author.Name == "" {
+			continue
+		}
+		key := strings.ToLower(c.Author.Email)
+		if key == "" {
+			key = c.Author.Name
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		authors = append(authors, c.Author)
+	}
+	sort.SliceStable(authors, func(i, j int) bool {
+		return strings.ToLower(authors[i].Name) < strings.ToLower(authors[j].Name)
+	})
+	return authors
+}
+
+// GenerateReleaseNotes renders commits as a markdown list, followed by the
+// contributors and the comparison link if opts has them
+func GenerateReleaseNotes(commits []*semrel.Commit, filters *Filters, matchRules []*MatchRule, opts *NotesOptions) string {
 	b := strings.Builder{}
 	for _, commit := range commits {
 		if filters != nil &&
@@ -75,7 +137,163 @@ func GenerateReleaseNotes(commits []*semrel.Commit, filters *Filters, matchRules
 			description = rule.Apply(description)
 		}
 		b.WriteString(description)
+		if url := opts.commitURL(commit); url != "" {
+			fmt.Fprintf(&b, " ([%s](%s))", commit.ShortHash(), url)
+		}
 		b.WriteString("\n")
 	}
+	if authors := opts.contributors(commits); len(authors) > 0 {
+		b.WriteString("\n### Contributors\n\n")
+		for _, a := range authors {
+			fmt.Fprintf(&b, "- %s\n", a.Name)
+		}
+	}
+	if url := opts.compareURL(); url != "" {
+		fmt.Fprintf(&b, "\n**Full Changelog**: [%s...%s](%s)\n", opts.Previous, opts.Tag, url)
+	}
 	return b.String()
 }
+
+// Section groups the commits of the given types under a title.
+type Section struct {
+	Title string
+	Types []string
+}
+
+func (s *Section) matchType(t string) bool {
+	for _, sectionType := range s.Types {
+		if strings.EqualFold(sectionType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSections are used when no sections are configured.
+var DefaultSections = []Section{
+	{Title: "Features", Types: []string{"feat"}},
+	{Title: "Bug Fixes", Types: []string{"fix"}},
+}
+
+// NotesData is passed to the release notes template.
+type NotesData struct {
+	// Breaking are the breaking changes of all types
+	Breaking []*NotesCommit
+	// Sections are the non-empty sections, in configured order
+	Sections []*NotesSection
+	// Contributors are the authors of the commits, if listed
+	Contributors []semrel.Author
+	// CompareURL is the link between the Previous and the released Tag, if
+	// known
+	Previous   string
+	Tag        string
+	CompareURL string
+}
+
+// NotesSection is a section with its commits, sorted by scope.
+type NotesSection struct {
+	Title   string
+	Commits []*NotesCommit
+}
+
+// NotesCommit is a commit and its link, if known
+type NotesCommit struct {
+	*semrel.Commit
+	URL string
+}
+
+// DefaultNotesTemplate renders the breaking changes, with the description of
+// their BREAKING CHANGE footer if any, and each section as a markdown list,
+// followed by the contributors and the comparison link if known.
+const DefaultNotesTemplate = `{{- define "link" }}{{ if .URL }} ([{{ .ShortHash }}]({{ .URL }})){{ end }}{{ end }}
+{{- define "commit" }}- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Description }}{{ template "link" . }}
+{{ end }}
+{{- define "breaking" }}- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ or .Breaking .Description }}{{ template "link" . }}
+{{ end }}
+{{- if .Breaking }}### Breaking Changes
+
+{{ range .Breaking }}{{ template "breaking" . }}{{ end }}
+{{- end }}
+{{- range $i, $s := .Sections }}{{ if or $i $.Breaking }}
+{{ end }}### {{ $s.Title }}
+
+{{ range $s.Commits }}{{ template "commit" . }}{{ end }}
+{{- end }}
+{{- if .Contributors }}{{ if or .Sections .Breaking }}
+{{ end }}### Contributors
+
+{{ range .Contributors }}- {{ .Name }}
+{{ end }}
+{{- end }}
+{{- if .CompareURL }}{{ if or .Sections .Breaking .Contributors }}
+{{ end }}**Full Changelog**: [{{ .Previous }}...{{ .Tag }}]({{ .CompareURL }})
+{{ end }}`
+
+var defaultNotesTemplate = template.Must(template.New("notes").Parse(DefaultNotesTemplate))
+
+// GenerateGroupedReleaseNotes groups commits by sections and renders them
+// through tmpl. Filters and match rules are applied before grouping. If
+// sections is empty, DefaultSections are used, and if tmpl is nil,
+// DefaultNotesTemplate.
+func GenerateGroupedReleaseNotes(commits []*semrel.Commit, filters *Filters, matchRules []*MatchRule, sections []Section, tmpl *template.Template, opts *NotesOptions) (string, error) {
+	if len(sections) == 0 {
+		sections = DefaultSections
+	}
+	if tmpl == nil {
+		tmpl = defaultNotesTemplate
+	}
+
+	data := &NotesData{
+		Contributors: opts.contributors(commits),
+		CompareURL:   opts.compareURL(),
+	}
+	if opts != nil {
+		data.Previous, data.Tag = opts.Previous, opts.Tag
+	}
+	grouped := make([][]*NotesCommit, len(sections))
+	for _, commit := range commits {
+		if filters != nil &&
+			(filters.MatchType(commit.Type) || filters.MatchScope(commit.Scope)) {
+			continue
+		}
+		// copy, so that match rules do not alter the caller's commits
+		c := *commit
+		for _, rule := range matchRules {
+			c.Description = rule.Apply(c.Description)
+		}
+		nc := &NotesCommit{Commit: &c, URL: opts.commitURL(&c)}
+		if c.IsBreaking() {
+			data.Breaking = append(data.Breaking, nc)
+		}
+		for i := range sections {
+			if sections[i].matchType(c.Type) {
+				grouped[i] = append(grouped[i], nc)
+				break
+			}
+		}
+	}
+
+	sortByScope(data.Breaking)
+	for i, cs := range grouped {
+		if len(cs) == 0 {
+			continue
+		}
+		sortByScope(cs)
+		data.Sections = append(data.Sections, &NotesSection{
+			Title:   sections[i].Title,
+			Commits: cs,
+		})
+	}
+
+	b := strings.Builder{}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func sortByScope(commits []*NotesCommit) {
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Scope < commits[j].Scope
+	})
+}
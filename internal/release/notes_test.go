@@ -3,6 +3,7 @@ package release
 import (
 	"regexp"
 	"testing"
+	"text/template"
 
 	"github.com/greatliontech/semrel/pkg/semrel"
 )
@@ -26,7 +27,7 @@ var testCommits = []*semrel.Commit{
 }
 
 func TestNotesSimple(t *testing.T) {
-	notes := GenerateReleaseNotes(testCommits, nil, nil)
+	notes := GenerateReleaseNotes(testCommits, nil, nil, nil)
 	exp := `- feat(core): Add new feature [TRACK-123]
 - docs(readme): Update README [TRACK-456]
 - fix(core): Fix a bug [TRACK-789]
@@ -41,7 +42,7 @@ func TestNotesFilters(t *testing.T) {
 	filters := &Filters{
 		Types: []string{"docs"},
 	}
-	notes := GenerateReleaseNotes(testCommits, filters, nil)
+	notes := GenerateReleaseNotes(testCommits, filters, nil, nil)
 	exp := `- feat(core): Add new feature [TRACK-123]
 - fix(core): Fix a bug [TRACK-789]
 `
@@ -58,7 +59,7 @@ func TestNotesMatchRules(t *testing.T) {
 			Replace: `[#$1](https://example.com/issue/$1)`,
 		},
 	}
-	notes := GenerateReleaseNotes(testCommits, nil, rules)
+	notes := GenerateReleaseNotes(testCommits, nil, rules, nil)
 	exp := `- feat(core): Add new feature [#TRACK-123](https://example.com/issue/TRACK-123)
 - docs(readme): Update README [#TRACK-456](https://example.com/issue/TRACK-456)
 - fix(core): Fix a bug [#TRACK-789](https://example.com/issue/TRACK-789)
@@ -80,7 +81,7 @@ func TestNotesNoScopes(t *testing.T) {
 			Description: "Fix a bug [TRACK-789]",
 		},
 	}
-	notes := GenerateReleaseNotes(commits, nil, nil)
+	notes := GenerateReleaseNotes(commits, nil, nil, nil)
 	exp := `- feat: Add new feature [TRACK-123]
 - fix: Fix a bug [TRACK-789]
 `
@@ -88,3 +89,200 @@ func TestNotesNoScopes(t *testing.T) {
 		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
 	}
 }
+
+var testGroupedCommits = []*semrel.Commit{
+	{
+		Type:        "fix",
+		Scope:       "web",
+		Description: "Fix a bug [TRACK-1]",
+	},
+	{
+		Type:        "feat",
+		Scope:       "core",
+		Description: "Drop the v1 API",
+		Attention:   true,
+	},
+	{
+		Type:        "docs",
+		Description: "Update README",
+	},
+	{
+		Type:        "fix",
+		Scope:       "api",
+		Description: "Fix another bug",
+		Breaking:    "Errors are returned as JSON",
+	},
+	{
+		Type:        "feat",
+		Description: "Add a feature",
+	},
+}
+
+func TestGroupedNotesDefault(t *testing.T) {
+	notes, err := GenerateGroupedReleaseNotes(testGroupedCommits, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `### Breaking Changes
+
+- **api:** Errors are returned as JSON
+- **core:** Drop the v1 API
+
+### Features
+
+- Add a feature
+- **core:** Drop the v1 API
+
+### Bug Fixes
+
+- **api:** Fix another bug
+- **web:** Fix a bug [TRACK-1]
+`
+	if notes != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
+	}
+}
+
+func TestGroupedNotesSectionsFiltersRules(t *testing.T) {
+	sections := []Section{
+		{Title: "Fixes", Types: []string{"fix"}},
+		{Title: "Documentation", Types: []string{"docs"}},
+	}
+	filters := &Filters{
+		Scopes: []string{"api"},
+	}
+	rules := []*MatchRule{
+		{
+			Match:   regexp.MustCompile(`\[(TRACK-\d+)\]`),
+			Replace: `[#$1](https://example.com/issue/$1)`,
+		},
+	}
+	notes, err := GenerateGroupedReleaseNotes(testGroupedCommits, filters, rules, sections, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `### Breaking Changes
+
+- **core:** Drop the v1 API
+
+### Fixes
+
+- **web:** Fix a bug [#TRACK-1](https://example.com/issue/TRACK-1)
+
+### Documentation
+
+- Update README
+`
+	if notes != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
+	}
+	if testGroupedCommits[0].Description != "Fix a bug [TRACK-1]" {
+		t.Errorf("expected commit description to be unchanged, got %q", testGroupedCommits[0].Description)
+	}
+}
+
+func TestGroupedNotesTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("custom").Parse(
+		`{{ range .Sections }}{{ .Title }}:{{ range .Commits }} {{ .Description }};{{ end }}
+{{ end }}`))
+	notes, err := GenerateGroupedReleaseNotes(testGroupedCommits, nil, nil, nil, tmpl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `Features: Add a feature; Drop the v1 API;
+Bug Fixes: Fix another bug; Fix a bug [TRACK-1];
+`
+	if notes != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
+	}
+}
+
+var testLinkedCommits = []*semrel.Commit{
+	{
+		Type:        "feat",
+		Description: "Add a feature",
+		Hash:        "0123456789abcdef",
+		Author:      semrel.Author{Name: "Jane Doe", Email: "jane@doe.org"},
+	},
+	{
+		Type:        "fix",
+		Description: "Fix a bug",
+		Hash:        "fedcba9876543210",
+		Author:      semrel.Author{Name: "John Doe", Email: "john@doe.org"},
+	},
+	{
+		Type:        "fix",
+		Description: "Fix another bug",
+		Author:      semrel.Author{Name: "Jane D.", Email: "JANE@doe.org"},
+	},
+}
+
+var testNotesOptions = &NotesOptions{
+	Links:        PlatformLinks("github", "https://github.com/owner/repo"),
+	Previous:     "v1.2.0",
+	Tag:          "v1.3.0",
+	Contributors: true,
+}
+
+func TestNotesLinks(t *testing.T) {
+	notes := GenerateReleaseNotes(testLinkedCommits, nil, nil, testNotesOptions)
+	exp := `- feat: Add a feature ([0123456](https://github.com/owner/repo/commit/0123456789abcdef))
+- fix: Fix a bug ([fedcba9](https://github.com/owner/repo/commit/fedcba9876543210))
+- fix: Fix another bug
+
+### Contributors
+
+- Jane Doe
+- John Doe
+
+**Full Changelog**: [v1.2.0...v1.3.0](https://github.com/owner/repo/compare/v1.2.0...v1.3.0)
+`
+	if notes != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
+	}
+}
+
+func TestGroupedNotesLinks(t *testing.T) {
+	notes, err := GenerateGroupedReleaseNotes(testLinkedCommits, nil, nil, nil, nil, testNotesOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `### Features
+
+- Add a feature ([0123456](https://github.com/owner/repo/commit/0123456789abcdef))
+
+### Bug Fixes
+
+- Fix a bug ([fedcba9](https://github.com/owner/repo/commit/fedcba9876543210))
+- Fix another bug
+
+### Contributors
+
+- Jane Doe
+- John Doe
+
+**Full Changelog**: [v1.2.0...v1.3.0](https://github.com/owner/repo/compare/v1.2.0...v1.3.0)
+`
+	if notes != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, notes)
+	}
+}
+
+func TestGroupedNotesTemplateCommitFields(t *testing.T) {
+	tmpl := template.Must(template.New("custom").Parse(
+		`{{ range .Sections }}{{ range .Commits }}{{ .ShortHash }} by {{ .Author.Name }}{{ with .Footer "Refs" }} refs {{ . }}{{ end }}
+{{ end }}{{ end }}`))
+	commits := []*semrel.Commit{{
+		Type:    "feat",
+		Hash:    "0123456789abcdef",
+		Author:  semrel.Author{Name: "Jane Doe"},
+		Footers: []semrel.Footer{{Token: "Refs", Value: "#42"}},
+	}}
+	notes, err := GenerateGroupedReleaseNotes(commits, nil, nil, nil, tmpl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "0123456 by Jane Doe refs #42\n"; notes != exp {
+		t.Errorf("expected %q, got %q", exp, notes)
+	}
+}
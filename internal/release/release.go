@@ -1,40 +1,96 @@
 package release
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
 	"strings"
+
+	"github.com/greatliontech/semrel/pkg/semrel"
 )
 
 type Releaser interface {
-	Release(tag, notes string) error
+	// Release creates the release of tag and returns its URL. If an asset of
+	// a new release fails to upload, no release is left behind.
+	Release(tag, notes string, opts *semrel.ReleaseOptions) (string, error)
+}
+
+// target is the commitish the tag of opts is created at, the commit if set,
+// otherwise the branch
+func target(opts *semrel.ReleaseOptions, branch string) string {
+	if opts.Target != "" {
+		return opts.Target
+	}
+	return branch
+}
+
+// warnSkippedAssets warns that the assets of opts are not uploaded to the
+// skipped release of tag
+func warnSkippedAssets(tag string, opts *semrel.ReleaseOptions) {
+	if len(opts.Assets) > 0 {
+		slog.Warn("release exists, its assets are not uploaded", "tag", tag, "assets", len(opts.Assets))
+	}
 }
 
-func Platform(platform, token, projectID, branch string) (Releaser, error) {
+// checkTarget returns ErrTagMismatch if tag resolves to commit instead of the
+// target commit of opts
+func checkTarget(tag, commit string, opts *semrel.ReleaseOptions) error {
+	if opts.Target == "" || commit == opts.Target {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is at %s, expected %s", ErrTagMismatch, tag, commit, opts.Target)
+}
+
+func Platform(platform, baseURL, token, projectID, branch string) (Releaser, error) {
 	platform = strings.ToLower(platform)
 	switch platform {
 	case "gitlab":
-		return NewGitlabReleaser(token, projectID, branch)
+		return NewGitlabReleaser(baseURL, token, projectID, branch)
 	case "github":
 		// split projectID into owner and repo
 		parts := strings.Split(projectID, "/")
-		return NewGithubReleaser(token, parts[0], parts[1], branch)
-	// case "gitea":
-	// 	return NewGiteaReleaser(token, projectID, branch)
+		return NewGithubReleaser(baseURL, token, parts[0], parts[1], branch)
+	case "gitea", "forgejo":
+		parts := strings.Split(projectID, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid project %q, expected owner/repo", projectID)
+		}
+		return NewGiteaReleaser(baseURL, token, parts[0], parts[1], branch)
 	default:
 		return nil, NewErrUnsupportedPlatform(platform)
 	}
 }
 
-func DetectPlatform() (string, string, string, error) {
+// DetectPlatform returns the platform, base URL, token and project from the
+// CI environment.
+func DetectPlatform() (string, string, string, string, error) {
 	if os.Getenv("GITLAB_CI") == "true" {
+		url := os.Getenv("CI_API_V4_URL")
 		token := os.Getenv("GITLAB_TOKEN")
 		project := os.Getenv("CI_PROJECT_ID")
-		return "gitlab", token, project, nil
+		return "gitlab", url, token, project, nil
+	}
+	// forgejo and gitea actions also set GITHUB_ACTIONS, so check them first
+	if os.Getenv("FORGEJO_ACTIONS") == "true" {
+		url := os.Getenv("FORGEJO_SERVER_URL")
+		token := os.Getenv("FORGEJO_TOKEN")
+		project := os.Getenv("FORGEJO_REPOSITORY")
+		return "forgejo", url, token, project, nil
+	}
+	if os.Getenv("GITEA_ACTIONS") == "true" {
+		url := os.Getenv("GITHUB_SERVER_URL")
+		token := os.Getenv("GITEA_TOKEN")
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		project := os.Getenv("GITHUB_REPOSITORY")
+		return "gitea", url, token, project, nil
 	}
 	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		url := os.Getenv("GITHUB_API_URL")
 		token := os.Getenv("GITHUB_TOKEN")
 		project := os.Getenv("GITHUB_REPOSITORY")
-		return "github", token, project, nil
+		return "github", url, token, project, nil
 	}
-	return "", "", "", ErrPlatformDetectionFailed
+	return "", "", "", "", ErrPlatformDetectionFailed
 }
@@ -3,12 +3,16 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
@@ -16,6 +20,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/greatliontech/semrel/pkg/semrel"
+	"golang.org/x/crypto/ssh"
 )
 
 func Open() (*Repo, error) {
@@ -31,13 +36,14 @@ func Open() (*Repo, error) {
 		return nil, fmt.Errorf("could not find .git directory: %w", err)
 	}
 
-	// open repository
-	r, err := git.PlainOpen(gitDir)
+	// open repository at its root, so that the worktree is available
+	root := filepath.Dir(gitDir)
+	r, err := git.PlainOpen(root)
 	if err != nil {
 		return nil, fmt.Errorf("could not open repository: %w", err)
 	}
 
-	return New(r, filepath.Dir(gitDir)), nil
+	return New(r, root), nil
 }
 
 type Repo struct {
@@ -61,124 +67,567 @@ func (r *Repo) Head() (plumbing.Hash, error) {
 	return ref.Hash(), nil
 }
 
-func (r *Repo) Commits(from, to plumbing.Hash) ([]*semrel.Commit, error) {
-	// get the commit log iterator
-	citr, err := r.repo.Log(&git.LogOptions{
-		From:  from,
-		Order: git.LogOrderCommitterTime,
-	})
+// Branch returns the short name of the checked out branch, or an empty string
+// if HEAD is detached
+func (r *Repo) Branch() (string, error) {
+	ref, err := r.repo.Head()
 	if err != nil {
-		slog.Error("could not get commit log", "error", err)
-		return nil, err
+		return "", err
+	}
+	if !ref.Name().IsBranch() {
+		return "", nil
+	}
+	return ref.Name().Short(), nil
+}
+
+// ResolveRevision returns the commit of a revision, e.g. a branch, tag or hash
+func (r *Repo) ResolveRevision(rev string) (plumbing.Hash, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not resolve %s: %w", rev, err)
 	}
+	return r.peelHash(*hash)
+}
+
+// LogEntry is a commit in a range and its parsed conventional commit message
+type LogEntry struct {
+	Hash    plumbing.Hash
+	Subject string
+	Message string
+	// Commit is nil if the message could not be parsed
+	Commit *semrel.Commit
+	// Reverts is the commit reverted by this one, if any
+	Reverts plumbing.Hash
+	// Reverted is true if this commit and its revert are both in the range,
+	// or if it reverts a commit in the range, so that neither counts
+	Reverted bool
+}
+
+// LogOptions narrow the commits of a range
+type LogOptions struct {
+	// Paths only keeps commits that changed a file under one of them
+	Paths []string
+	// FirstParent only follows the first parent of merges, so that merged
+	// branches count as their merge commit, as in squash merge workflows
+	FirstParent bool
+	// Parser parses the commit messages, conventional commits if nil
+	Parser semrel.CommitParser
+}
 
-	errBreak := errors.New("break")
+// Commits returns the parsed commits reachable from from, but not from to.
+func (r *Repo) Commits(from, to plumbing.Hash, opts *LogOptions) ([]*semrel.Commit, error) {
+	entries, err := r.Log(from, to, opts)
+	if err != nil {
+		return nil, err
+	}
 	commits := []*semrel.Commit{}
-	err = citr.ForEach(func(c *object.Commit) error {
-		if c.Hash == to {
-			return errBreak
+	for _, e := range entries {
+		if e.Commit != nil && !e.Reverted {
+			commits = append(commits, e.Commit)
 		}
-		cmt, err := semrel.ParseCommitMessage(c.Message)
+	}
+	return commits, nil
+}
+
+// Log returns all commits reachable from from, but not from to, including the
+// ones the parser cannot parse, newest first. A zero from is HEAD, a
+// zero to the whole history.
+func (r *Repo) Log(from, to plumbing.Hash, opts *LogOptions) ([]*LogEntry, error) {
+	if opts == nil {
+		opts = &LogOptions{}
+	}
+	parser := opts.Parser
+	if parser == nil {
+		parser = semrel.ConventionalParser{}
+	}
+
+	// annotated tags are peeled, so that the range ends at the tagged commit
+	from, err := r.peelHash(from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = r.peelHash(to)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() {
+		from, err = r.Head()
 		if err != nil {
-			if err == semrel.ErrNotConventionalCommit {
+			return nil, err
+		}
+	}
+
+	released, err := r.ancestors(to)
+	if err != nil {
+		slog.Error("could not get released commits", "error", err)
+		return nil, err
+	}
+
+	start, err := r.repo.CommitObject(from)
+	if err != nil {
+		return nil, err
+	}
+	var citr object.CommitIter
+	if opts.FirstParent {
+		citr = newFirstParentIter(start, released)
+	} else {
+		citr = object.NewCommitPreorderIter(start, released, nil)
+	}
+
+	commits := []*object.Commit{}
+	err = citr.ForEach(func(c *object.Commit) error {
+		if len(opts.Paths) > 0 {
+			ok, err := touchesPaths(c, opts.Paths)
+			if err != nil {
+				return err
+			}
+			if !ok {
 				return nil
 			}
-			return err
 		}
-		commits = append(commits, cmt)
+		commits = append(commits, c)
 		return nil
 	})
-	if err != nil && err != errBreak {
+	if err != nil {
 		slog.Error("could not iterate over commits", "error", err)
 		return nil, err
 	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Committer.When.After(commits[j].Committer.When)
+	})
+
+	entries := []*LogEntry{}
+	for _, c := range commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		entry := &LogEntry{Hash: c.Hash, Subject: subject, Message: c.Message}
+		cmt, err := parser.Parse(c.Message)
+		if err != nil && err != semrel.ErrNotConventionalCommit {
+			return nil, err
+		}
+		if cmt != nil {
+			cmt.Hash = c.Hash.String()
+			cmt.Author = semrel.Author{Name: c.Author.Name, Email: c.Author.Email}
+			cmt.Date = c.Author.When
+		}
+		entry.Commit = cmt
+		entries = append(entries, entry)
+	}
+	markReverts(entries)
+	return entries, nil
+}
 
-	return commits, nil
+// markReverts marks the reverts of commits in entries, newest first, and the
+// reverted commits. A revert of a revert restores the originally reverted
+// commit.
+func markReverts(entries []*LogEntry) {
+	byHash := map[plumbing.Hash]*LogEntry{}
+	for _, e := range entries {
+		byHash[e.Hash] = e
+	}
+	// an ambiguous short hash reverts none of its matches
+	find := func(prefix string) *LogEntry {
+		if len(prefix) == 40 {
+			return byHash[plumbing.NewHash(prefix)]
+		}
+		var found *LogEntry
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Hash.String(), prefix) {
+				continue
+			}
+			if found != nil {
+				slog.Warn("ambiguous reverted commit, ignoring the revert", "hash", prefix)
+				return nil
+			}
+			found = e
+		}
+		return found
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		prefix, ok := semrel.RevertedHash(e.Message)
+		if !ok {
+			continue
+		}
+		reverted := find(prefix)
+		if reverted == nil {
+			continue
+		}
+		e.Reverts = reverted.Hash
+		e.Reverted = true
+		if !reverted.Reverted {
+			reverted.Reverted = true
+			continue
+		}
+		// reverting a revert brings back what it reverted
+		reverted.Reverted = true
+		if original, ok := byHash[reverted.Reverts]; ok {
+			original.Reverted = false
+		}
+	}
+}
+
+// ancestors returns the commits reachable from hash, including itself
+func (r *Repo) ancestors(hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	seen := map[plumbing.Hash]bool{}
+	if hash.IsZero() {
+		return seen, nil
+	}
+	c, err := r.repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	err = object.NewCommitPreorderIter(c, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seen, nil
 }
 
 var emptyVersion = semver.New(0, 0, 0, "", "")
 
-type versionReference struct {
-	ver *semver.Version
-	ref *plumbing.Reference
+// VersionReference is a version and the tag it was parsed from
+type VersionReference struct {
+	Version *semver.Version
+	Ref     *plumbing.Reference
+	// Commit is the tagged commit, which for annotated tags differs from the
+	// hash of Ref
+	Commit plumbing.Hash
+}
+
+// CurrentVersion returns the highest version tagged in the given format and
+// its tag, or nil if there is none. Tags not matching the format are ignored,
+// as are prereleases unless included.
+func (r *Repo) CurrentVersion(format *semrel.TagFormat, currentBranchOnly, includePrereleases bool) (*semver.Version, *VersionReference, error) {
+	versions, err := r.Versions(format, currentBranchOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, v := range versions {
+		if includePrereleases || v.Version.Prerelease() == "" {
+			return v.Version, &versions[i], nil
+		}
+	}
+	return emptyVersion, nil, nil
 }
 
-func (r *Repo) CurrentVersion(currentBranchOnly bool) (*semver.Version, *plumbing.Reference, error) {
+// Versions returns all versions tagged in the given format, in descending
+// order. Tags not matching the format are ignored.
+func (r *Repo) Versions(format *semrel.TagFormat, currentBranchOnly bool) ([]VersionReference, error) {
 	currentBranchRefs := mapset.NewSet[plumbing.Hash]()
 
 	if currentBranchOnly {
 		head, err := r.repo.Head()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		litr, err := r.repo.Log(&git.LogOptions{
 			From:  head.Hash(),
 			Order: git.LogOrderCommitterTime,
 		})
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		err = litr.ForEach(func(c *object.Commit) error {
 			currentBranchRefs.Add(c.Hash)
 			return nil
 		})
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
 	// get the tag iterator
 	titr, err := r.repo.Tags()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	versions := []versionReference{}
+	versions := []VersionReference{}
 
 	err = titr.ForEach(func(ref *plumbing.Reference) error {
+		ver, ok := format.Version(ref.Name().Short())
+		if !ok {
+			return nil
+		}
+		commit, err := r.PeelTag(ref)
+		if err != nil {
+			return err
+		}
 		if currentBranchOnly {
-			if !currentBranchRefs.Contains(ref.Hash()) {
+			if !currentBranchRefs.Contains(commit) {
 				return nil
 			}
 		}
-		ver, err := semver.NewVersion(ref.Name().Short())
-		if err == nil {
-			versions = append(versions, versionReference{ver: ver, ref: ref})
-		}
+		versions = append(versions, VersionReference{Version: ver, Ref: ref, Commit: commit})
 		return nil
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// sort the versions in descending order
 	sort.Slice(versions, func(i, j int) bool {
-		v1 := versions[i].ver
-		v2 := versions[j].ver
+		v1 := versions[i].Version
+		v2 := versions[j].Version
 		return v2.LessThan(v1)
 	})
-	if len(versions) == 0 {
-		return emptyVersion, nil, nil
+	return versions, nil
+}
+
+// PeelTag returns the commit a tag points to, following annotated tags
+func (r *Repo) PeelTag(ref *plumbing.Reference) (plumbing.Hash, error) {
+	return r.peelHash(ref.Hash())
+}
+
+// peelHash follows the tag objects starting at hash until a non tag object.
+// Any other hash is returned as is.
+func (r *Repo) peelHash(hash plumbing.Hash) (plumbing.Hash, error) {
+	for !hash.IsZero() {
+		tag, err := r.repo.TagObject(hash)
+		if err == plumbing.ErrObjectNotFound {
+			return hash, nil
+		}
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		hash = tag.Target
+	}
+	return hash, nil
+}
+
+// CommitTime returns the committer time of the commit with the given hash
+func (r *Repo) CommitTime(hash plumbing.Hash) (time.Time, error) {
+	hash, err := r.peelHash(hash)
+	if err != nil {
+		return time.Time{}, err
+	}
+	c, err := r.repo.CommitObject(hash)
+	if err != nil {
+		return time.Time{}, err
 	}
-	return versions[0].ver, versions[0].ref, nil
+	return c.Committer.When, nil
 }
 
-func (r *Repo) CreateTag(tag string, commit plumbing.Hash, push bool, auth transport.AuthMethod) error {
-	_, err := r.repo.CreateTag(tag, commit, nil)
+// ErrStagedChanges is returned if files other than the ones to commit are
+// staged, as they would be committed along
+var ErrStagedChanges = errors.New("changes are staged")
+
+// CommitFiles commits the files at paths, relative to the root, as the user of
+// the git config and returns the new HEAD. Nothing is committed and the zero
+// hash returned if the files are unchanged. Other staged changes are an error.
+func (r *Repo) CommitFiles(paths []string, message string) (plumbing.Hash, error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	status, err := w.Status()
 	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	files := map[string]bool{}
+	for _, p := range paths {
+		files[filepath.ToSlash(p)] = true
+	}
+	staged := []string{}
+	for p, fs := range status {
+		if !files[p] && fs.Staging != git.Unmodified && fs.Staging != git.Untracked {
+			staged = append(staged, p)
+		}
+	}
+	if len(staged) > 0 {
+		sort.Strings(staged)
+		return plumbing.ZeroHash, fmt.Errorf("%w, unstage or commit them first: %s", ErrStagedChanges, strings.Join(staged, ", "))
+	}
+	for _, p := range paths {
+		if _, err := w.Add(filepath.ToSlash(p)); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	status, err = w.Status()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	changed := false
+	for _, p := range paths {
+		if fs, ok := status[filepath.ToSlash(p)]; ok && fs.Staging != git.Unmodified {
+			changed = true
+		}
+	}
+	if !changed {
+		return plumbing.ZeroHash, nil
+	}
+	sig, err := r.configSignature()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return w.Commit(message, &git.CommitOptions{Author: sig, Committer: sig})
+}
+
+// PushOptions select the remote to push to and how to authenticate
+type PushOptions struct {
+	// Remote is the name of the remote, origin if empty
+	Remote string
+	Auth   transport.AuthMethod
+	// Branch also pushes the checked out branch to the remote branch of the
+	// same name, in the same push as the tag
+	Branch bool
+}
+
+func (o *PushOptions) remote() string {
+	if o.Remote == "" {
+		return git.DefaultRemoteName
+	}
+	return o.Remote
+}
+
+// RemoteURL returns the first URL of the remote with the given name
+func (r *Repo) RemoteURL(name string) (string, error) {
+	remote, err := r.repo.Remote(name)
+	if err != nil {
+		return "", fmt.Errorf("remote %s: %w", name, err)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %s has no URL", name)
+	}
+	return urls[0], nil
+}
+
+// TagExists returns whether the tag exists
+func (r *Repo) TagExists(tag string) (bool, error) {
+	_, err := r.repo.Tag(tag)
+	if errors.Is(err, git.ErrTagNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// TagOptions make CreateTag create an annotated tag, signed if a key is set
+type TagOptions struct {
+	// Message of the tag
+	Message string
+	// Tagger of the tag. If nil, the committer or user of the git config is used
+	Tagger *object.Signature
+	// PGPKey signs the tag with OpenPGP
+	PGPKey *openpgp.Entity
+	// SSHSigner signs the tag with SSH
+	SSHSigner ssh.Signer
+}
+
+// CreateTag creates a lightweight tag at commit, or an annotated one if opts
+// is not nil, and pushes it if push is not nil. The tag is created locally
+// before anything is pushed, and the branch is pushed along with it if asked
+// to, so that a failure leaves nothing half-published.
+func (r *Repo) CreateTag(tag string, commit plumbing.Hash, opts *TagOptions, push *PushOptions) error {
+	refSpecs := []config.RefSpec{config.RefSpec(fmt.Sprintf("refs/tags/%s:refs/tags/%s", tag, tag))}
+	if push != nil && push.Branch {
+		head, err := r.repo.Head()
+		if err != nil {
+			return err
+		}
+		if !head.Name().IsBranch() {
+			return errors.New("HEAD is detached, there is no branch to push")
+		}
+		refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name())))
+	}
+
+	var err error
+	if opts == nil {
+		_, err = r.repo.CreateTag(tag, commit, nil)
+	} else {
+		err = r.createAnnotatedTag(tag, commit, opts)
+	}
+	if err != nil || push == nil {
 		return err
 	}
 
-	if push {
-		refSpecStr := fmt.Sprintf("refs/tags/%s:refs/tags/%s", tag, tag)
-		refSpec := config.RefSpec(refSpecStr)
+	return r.repo.Push(&git.PushOptions{
+		RemoteName: push.remote(),
+		RefSpecs:   refSpecs,
+		Auth:       push.Auth,
+	})
+}
 
-		return r.repo.Push(&git.PushOptions{
-			RefSpecs: []config.RefSpec{
-				refSpec,
-			},
-			Auth: auth,
+func (r *Repo) createAnnotatedTag(tag string, commit plumbing.Hash, opts *TagOptions) error {
+	tagger := opts.Tagger
+	if tagger == nil {
+		var err error
+		tagger, err = r.configSignature()
+		if err != nil {
+			return err
+		}
+	}
+	if opts.SSHSigner == nil {
+		_, err := r.repo.CreateTag(tag, commit, &git.CreateTagOptions{
+			Tagger:  tagger,
+			Message: opts.Message,
+			SignKey: opts.PGPKey,
 		})
+		return err
+	}
+
+	// go-git can only sign tags with OpenPGP, so build the tag object here
+	name := plumbing.NewTagReferenceName(tag)
+	if _, err := r.repo.Reference(name, false); err == nil {
+		return git.ErrTagExists
+	}
+	t := &object.Tag{
+		Name:       tag,
+		Tagger:     *tagger,
+		Message:    strings.TrimSpace(opts.Message) + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     commit,
+	}
+	unsigned := &plumbing.MemoryObject{}
+	if err := t.Encode(unsigned); err != nil {
+		return err
 	}
+	rd, err := unsigned.Reader()
+	if err != nil {
+		return err
+	}
+	payload, err := io.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	sig, err := sshSign(opts.SSHSigner, payload)
+	if err != nil {
+		return err
+	}
+	t.PGPSignature = sig
 
-	return err
+	obj := r.repo.Storer.NewEncodedObject()
+	if err := t.Encode(obj); err != nil {
+		return err
+	}
+	hash, err := r.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return err
+	}
+	return r.repo.Storer.SetReference(plumbing.NewHashReference(name, hash))
+}
+
+// configSignature returns the committer, or else the user, of the git config
+func (r *Repo) configSignature() (*object.Signature, error) {
+	cfg, err := r.repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, err
+	}
+	tagger := &object.Signature{
+		Name:  cfg.Committer.Name,
+		Email: cfg.Committer.Email,
+		When:  time.Now(),
+	}
+	if tagger.Name == "" {
+		tagger.Name = cfg.User.Name
+	}
+	if tagger.Email == "" {
+		tagger.Email = cfg.User.Email
+	}
+	if tagger.Name == "" || tagger.Email == "" {
+		return nil, errors.New("git user name and email are not configured")
+	}
+	return tagger, nil
 }
@@ -1,16 +1,29 @@
 package repository
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	mapset "github.com/deckarep/golang-set/v2"
+
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/greatliontech/semrel/pkg/semrel"
+	"golang.org/x/crypto/ssh"
 )
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -24,8 +37,11 @@ func randString(n int) string {
 }
 
 type testCommit struct {
-	msg string
-	tag string
+	msg   string
+	tag   string
+	files []string
+	// annotated creates an annotated instead of a lightweight tag
+	annotated bool
 }
 
 func testRepo(cms []testCommit) (*git.Repository, error) {
@@ -43,20 +59,26 @@ func testRepo(cms []testCommit) (*git.Repository, error) {
 	}
 
 	for _, cm := range cms {
-		file, err := f.Create(randString(10))
-		if err != nil {
-			return nil, err
-		}
-		_, err = file.Write([]byte("test content"))
-		if err != nil {
-			return nil, err
-		}
-		if err := file.Close(); err != nil {
-			return nil, err
+		files := cm.files
+		if len(files) == 0 {
+			files = []string{randString(10)}
 		}
-		_, err = w.Add(file.Name())
-		if err != nil {
-			return nil, err
+		for _, name := range files {
+			file, err := f.Create(name)
+			if err != nil {
+				return nil, err
+			}
+			_, err = file.Write([]byte(randString(10)))
+			if err != nil {
+				return nil, err
+			}
+			if err := file.Close(); err != nil {
+				return nil, err
+			}
+			_, err = w.Add(name)
+			if err != nil {
+				return nil, err
+			}
 		}
 		commit, err := w.Commit(cm.msg, &git.CommitOptions{
 			Author: &object.Signature{
@@ -69,7 +91,18 @@ func testRepo(cms []testCommit) (*git.Repository, error) {
 			return nil, err
 		}
 		if cm.tag != "" {
-			_, err = r.CreateTag(cm.tag, commit, nil)
+			var opts *git.CreateTagOptions
+			if cm.annotated {
+				opts = &git.CreateTagOptions{
+					Tagger: &object.Signature{
+						Name:  "John Doe",
+						Email: "john@doe.org",
+						When:  time.Now(),
+					},
+					Message: cm.tag,
+				}
+			}
+			_, err = r.CreateTag(cm.tag, commit, opts)
 			if err != nil {
 				return nil, err
 			}
@@ -89,7 +122,7 @@ func TestCommits(t *testing.T) {
 		t.Fatal(err)
 	}
 	repo := New(r, "/tmp/test")
-	commits, err := repo.Commits(plumbing.ZeroHash, plumbing.ZeroHash)
+	commits, err := repo.Commits(plumbing.ZeroHash, plumbing.ZeroHash, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -104,5 +137,747 @@ func TestCommits(t *testing.T) {
 		if c.Type != expected[i].Type {
 			t.Fatalf("expected %s, got %s", expected[i].Type, c.Type)
 		}
+		if len(c.Hash) != 40 || c.Author.Email != "john@doe.org" || c.Date.IsZero() {
+			t.Errorf("expected the hash, author and date, got %s by %+v at %s", c.Hash, c.Author, c.Date)
+		}
+	}
+}
+
+func TestCommitsPaths(t *testing.T) {
+	commitMessages := []testCommit{
+		{msg: "initial", tag: ""},
+		{msg: "fix(api): bug", files: []string{"services/api/main.go"}},
+		{msg: "feat(web): new feature", files: []string{"services/web/main.go"}},
+		{msg: "feat: shared feature", files: []string{"services/api/go.mod", "services/web/go.mod"}},
+		{msg: "chore: similar name", files: []string{"services/api-gateway/main.go"}},
+	}
+	r, err := testRepo(commitMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	commits, err := repo.Commits(plumbing.ZeroHash, plumbing.ZeroHash, &LogOptions{Paths: []string{"services/api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"shared feature", "bug"}
+	if len(commits) != len(expected) {
+		t.Fatalf("expected %d commits, got %d", len(expected), len(commits))
+	}
+	for i, c := range commits {
+		if c.Description != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], c.Description)
+		}
+	}
+}
+
+func TestCommitsParser(t *testing.T) {
+	r, err := testRepo([]testCommit{
+		{msg: "initial"},
+		{msg: ":sparkles: add login"},
+		{msg: "feat: not gitmoji"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	entries, err := repo.Log(plumbing.ZeroHash, plumbing.ZeroHash, &LogOptions{Parser: semrel.GitmojiParser{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := 0
+	for _, e := range entries {
+		if e.Commit != nil {
+			parsed++
+			if e.Commit.Type != "feat" || e.Commit.Description != "add login" {
+				t.Errorf("unexpected commit %+v", *e.Commit)
+			}
+		}
+	}
+	if len(entries) != 3 || parsed != 1 {
+		t.Fatalf("expected 3 entries with 1 parsed, got %d with %d", len(entries), parsed)
+	}
+}
+
+func TestCurrentVersionPrefix(t *testing.T) {
+	commitMessages := []testCommit{
+		{msg: "initial", tag: "v1.0.0"},
+		{msg: "feat: api", tag: "api/v0.3.0"},
+		{msg: "feat: web", tag: "web/v2.1.0"},
+		{msg: "fix: api", tag: "api/v0.3.1"},
+		{msg: "fix: root", tag: "v1.0.1"},
+	}
+	r, err := testRepo(commitMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	tests := map[string]string{
+		"":      "1.0.1",
+		"v":     "1.0.1",
+		"api/v": "0.3.1",
+		"web/v": "2.1.0",
+		"db/v":  "0.0.0",
+	}
+	for prefix, want := range tests {
+		format, err := semrel.DefaultConfig.TagFormat(semrel.Package{Prefix: prefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ver, _, err := repo.CurrentVersion(format, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver.String() != want {
+			t.Errorf("prefix %q: expected %s, got %s", prefix, want, ver)
+		}
+	}
+}
+
+func TestCurrentVersionTagTemplate(t *testing.T) {
+	cfg, err := semrel.NewConfig(semrel.WithPrefix("release-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := cfg.TagFormat(semrel.Package{Prefix: cfg.Prefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitMessages := []testCommit{
+		{msg: "initial", tag: "v3.0.0"},
+		{msg: "feat: a feature", tag: "release-1.1.0"},
+		{msg: "fix: a fix", tag: "release-latest"},
+	}
+	r, err := testRepo(commitMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	ver, _, err := repo.CurrentVersion(format, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver.String() != "1.1.0" {
+		t.Fatalf("expected 1.1.0, got %s", ver)
+	}
+
+	// a tag created from the format must be found on the next run
+	next := ver.IncMinor()
+	tag, err := format.Tag(&next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateTag(tag, head, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	ver, _, err = repo.CurrentVersion(format, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ver.Equal(&next) {
+		t.Fatalf("expected %s, got %s", next.String(), ver)
+	}
+}
+
+func TestCurrentVersionAnnotated(t *testing.T) {
+	cfg, err := semrel.NewConfig(semrel.WithPrefix("v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := cfg.TagFormat(semrel.Package{Prefix: cfg.Prefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitMessages := []testCommit{
+		{msg: "initial", tag: "v1.0.0"},
+		{msg: "feat: a feature", tag: "v1.1.0", annotated: true},
+		{msg: "fix: a fix"},
+		{msg: "fix: another fix", tag: "v1.1.1"},
+		{msg: "feat: another feature", tag: "v1.2.0", annotated: true},
+		{msg: "fix: a last fix"},
+	}
+	r, err := testRepo(commitMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+
+	for _, cbo := range []bool{false, true} {
+		ver, ref, err := repo.CurrentVersion(format, cbo, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver.String() != "1.2.0" {
+			t.Fatalf("current branch only %t: expected 1.2.0, got %s", cbo, ver)
+		}
+		c, err := r.CommitObject(ref.Commit)
+		if err != nil {
+			t.Fatalf("expected the tagged commit: %s", err)
+		}
+		if c.Message != "feat: another feature" {
+			t.Fatalf("expected the tagged commit, got %q", c.Message)
+		}
+		commits, err := repo.Commits(plumbing.ZeroHash, ref.Ref.Hash(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commits) != 1 {
+			t.Fatalf("expected 1 commit since the annotated tag, got %d", len(commits))
+		}
+	}
+
+	versions, err := repo.Versions(format, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 4 {
+		t.Fatalf("expected 4 versions, got %d", len(versions))
+	}
+	// the range between two annotated tags holds the commits in between
+	commits, err := repo.Commits(versions[0].Commit, versions[2].Ref.Hash(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("expected 3 commits between v1.1.0 and v1.2.0, got %d", len(commits))
+	}
+	if _, err := repo.CommitTime(versions[0].Ref.Hash()); err != nil {
+		t.Fatalf("expected the time of the tagged commit: %s", err)
+	}
+}
+
+func TestCurrentVersionAnnotatedOtherBranch(t *testing.T) {
+	cfg, err := semrel.NewConfig(semrel.WithPrefix("v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := cfg.TagFormat(semrel.Package{Prefix: cfg.Prefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := testRepo([]testCommit{
+		{msg: "initial", tag: "v1.0.0", annotated: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()}
+
+	// tag a commit on another branch, then go back
+	err = w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("other"), Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := w.Commit("feat: elsewhere", &git.CommitOptions{Author: sig, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v2.0.0", other, &git.CreateTagOptions{Tagger: sig, Message: "v2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := New(r, "/tmp/test")
+	ver, _, err := repo.CurrentVersion(format, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver.String() != "2.0.0" {
+		t.Fatalf("expected 2.0.0, got %s", ver)
+	}
+	ver, _, err = repo.CurrentVersion(format, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver.String() != "1.0.0" {
+		t.Fatalf("expected 1.0.0 on the current branch, got %s", ver)
+	}
+}
+
+func TestCurrentVersionPrereleases(t *testing.T) {
+	cfg, err := semrel.NewConfig(semrel.WithPrefix("v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := cfg.TagFormat(semrel.Package{Prefix: cfg.Prefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := testRepo([]testCommit{
+		{msg: "initial", tag: "v1.2.0"},
+		{msg: "feat: a feature", tag: "v1.3.0-rc.1"},
+		{msg: "fix: a fix", tag: "v1.3.0-rc.2", annotated: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	for include, want := range map[bool]string{false: "1.2.0", true: "1.3.0-rc.2"} {
+		ver, _, err := repo.CurrentVersion(format, true, include)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver.String() != want {
+			t.Errorf("include prereleases %t: expected %s, got %s", include, want, ver)
+		}
+	}
+}
+
+func TestCommitsReverts(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "initial", tag: "v1.0.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(msg string) plumbing.Hash {
+		t.Helper()
+		h, err := w.Commit(msg, &git.CommitOptions{
+			Author:            &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+			AllowEmptyCommits: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	released, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feat := commit("feat: add login")
+	commit("Revert \"feat: add login\"\n\nThis reverts commit " + feat.String() + ".\n")
+	fix := commit("fix: handle nil")
+	revertFix := commit("revert: fix: handle nil\n\nThis reverts commit " + fix.String()[:7] + ".\n")
+	commit("Revert \"revert: fix: handle nil\"\n\nThis reverts commit " + revertFix.String() + ".\n")
+	commit("revert: fix: released\n\nThis reverts commit " + released.Hash().String() + ".\n")
+
+	repo := New(r, "/tmp/test")
+	commits, err := repo.Commits(plumbing.ZeroHash, released.Hash(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, c := range commits {
+		got = append(got, c.Type+": "+c.Description)
+	}
+	// the feat is reverted, the revert of the fix is reverted, and the revert
+	// of a released commit counts
+	want := mapset.NewSet("revert: fix: released", "fix: handle nil")
+	if !mapset.NewSet(got...).Equal(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMarkRevertsAmbiguous(t *testing.T) {
+	a := &LogEntry{Hash: plumbing.NewHash("abcdef1a00000000000000000000000000000000"), Message: "feat: a\n"}
+	b := &LogEntry{Hash: plumbing.NewHash("abcdef1b00000000000000000000000000000000"), Message: "feat: b\n"}
+	ambiguous := &LogEntry{Hash: plumbing.NewHash("1111111000000000000000000000000000000000"), Message: "revert: feat\n\nThis reverts commit abcdef1.\n"}
+	unique := &LogEntry{Hash: plumbing.NewHash("2222222000000000000000000000000000000000"), Message: "revert: feat: b\n\nThis reverts commit abcdef1b.\n"}
+	// newest first
+	markReverts([]*LogEntry{unique, ambiguous, b, a})
+	if a.Reverted || ambiguous.Reverted {
+		t.Error("expected the ambiguous revert to revert nothing")
+	}
+	if !b.Reverted || !unique.Reverted || unique.Reverts != b.Hash {
+		t.Error("expected the unique revert to revert b")
+	}
+}
+
+func TestCommitsMerges(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "initial", tag: "v1.0.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trunk, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()}
+	commit := func(msg string, parents ...plumbing.Hash) plumbing.Hash {
+		t.Helper()
+		h, err := w.Commit(msg, &git.CommitOptions{Author: sig, AllowEmptyCommits: true, Parents: parents})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	checkout := func(branch string, create bool) {
+		t.Helper()
+		err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: create})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a feature branch, whose first commit is tagged, merged after a fix on
+	// main was released
+	checkout("feature", true)
+	tagged := commit("feat: first feature")
+	if _, err := r.CreateTag("v1.1.0", tagged, nil); err != nil {
+		t.Fatal(err)
+	}
+	feature := commit("feat: second feature")
+	checkout(trunk.Name().Short(), false)
+	released := commit("fix: a fix")
+	if _, err := r.CreateTag("v1.0.1", released, nil); err != nil {
+		t.Fatal(err)
+	}
+	commit("Merge branch 'feature'", released, feature)
+
+	repo := New(r, "/tmp/test")
+	tests := []struct {
+		name string
+		to   plumbing.Hash
+		opts *LogOptions
+		want []string
+	}{
+		{"since main tag", released, nil, []string{"Merge branch 'feature'", "feat: second feature", "feat: first feature"}},
+		{"since side branch tag", tagged, nil, []string{"Merge branch 'feature'", "fix: a fix", "feat: second feature"}},
+		{"first parent", released, &LogOptions{FirstParent: true}, []string{"Merge branch 'feature'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := repo.Log(plumbing.ZeroHash, tt.to, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := mapset.NewSet[string]()
+			for _, e := range entries {
+				got.Add(e.Subject)
+			}
+			if !got.Equal(mapset.NewSet(tt.want...)) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommitFiles(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "initial", files: []string{"package.json"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name = "Jane Doe"
+	cfg.User.Email = "jane@doe.org"
+	if err := r.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := w.Filesystem.Create("package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(`{"version": "1.1.0"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := New(r, "/tmp/test")
+	hash, err := repo.CommitFiles([]string{"package.json"}, "chore(release): v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != hash {
+		t.Fatalf("expected HEAD at %s, got %s", hash, head)
+	}
+	c, err := r.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Message != "chore(release): v1.1.0" || c.Author.Email != "jane@doe.org" {
+		t.Errorf("unexpected commit %q by %s", c.Message, c.Author.Email)
+	}
+	ok, err := touchesPaths(c, []string{"package.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected package.json to be committed")
+	}
+
+	// unchanged files are not committed again
+	hash, err = repo.CommitFiles([]string{"package.json"}, "chore(release): v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hash.IsZero() {
+		t.Errorf("expected no commit, got %s", hash)
+	}
+
+	// unrelated staged changes are not committed along
+	for name, content := range map[string]string{"package.json": `{"version": "1.2.0"}`, "notes.txt": "wip"} {
+		f, err := w.Filesystem.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.Add("notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CommitFiles([]string{"package.json"}, "chore(release): v1.2.0"); !errors.Is(err, ErrStagedChanges) {
+		t.Errorf("expected ErrStagedChanges, got %v", err)
+	}
+	if h, err := repo.Head(); err != nil || h != head {
+		t.Errorf("expected HEAD to stay at %s, got %s", head, h)
+	}
+}
+
+func TestCreateTagPushRemote(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "feat: a feature"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	upstream, err := git.PlainInit(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	url, err := repo.RemoteURL("upstream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != dir {
+		t.Errorf("expected remote URL %s, got %s", dir, url)
+	}
+	if _, err := repo.RemoteURL("origin"); err == nil {
+		t.Error("expected error for missing origin remote")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.TagExists("v1.0.0"); err != nil || ok {
+		t.Fatalf("expected no tag v1.0.0, got %v, %v", ok, err)
+	}
+	push := &PushOptions{Remote: "upstream", Branch: true}
+	if err := repo.CreateTag("v1.0.0", head, nil, push); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.TagExists("v1.0.0"); err != nil || !ok {
+		t.Fatalf("expected tag v1.0.0, got %v, %v", ok, err)
+	}
+	branch, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteBranch, err := upstream.Reference(branch.Name(), true)
+	if err != nil {
+		t.Fatalf("expected the branch on the remote: %s", err)
+	}
+	if remoteBranch.Hash() != head {
+		t.Errorf("expected the branch at %s, got %s", head, remoteBranch.Hash())
+	}
+	ref, err := upstream.Tag("v1.0.0")
+	if err != nil {
+		t.Fatalf("expected the tag on the remote: %s", err)
+	}
+	if ref.Hash() != head {
+		t.Errorf("expected the tag at %s, got %s", head, ref.Hash())
+	}
+}
+
+func TestCreateAnnotatedTag(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "feat: a feature"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger := &object.Signature{Name: "Jane Doe", Email: "jane@doe.org", When: time.Now()}
+	err = repo.CreateTag("v1.0.0", head, &TagOptions{Message: "release notes", Tagger: tagger}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := testTagObject(t, r, "v1.0.0")
+	if tag.Message != "release notes\n" {
+		t.Errorf("expected message %q, got %q", "release notes\n", tag.Message)
+	}
+	if tag.Tagger.Email != tagger.Email {
+		t.Errorf("expected tagger %s, got %s", tagger.Email, tag.Tagger.Email)
+	}
+	if tag.Target != head {
+		t.Errorf("expected target %s, got %s", head, tag.Target)
+	}
+}
+
+func TestCreatePGPSignedTag(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "feat: a feature"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := openpgp.NewEntity("Jane Doe", "", "jane@doe.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger := &object.Signature{Name: "Jane Doe", Email: "jane@doe.org", When: time.Now()}
+	err = repo.CreateTag("v1.0.0", head, &TagOptions{Message: "signed", Tagger: tagger, PGPKey: key}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &bytes.Buffer{}
+	w, err := armor.Encode(pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tag := testTagObject(t, r, "v1.0.0")
+	if _, err := tag.Verify(pub.String()); err != nil {
+		t.Fatalf("signature does not verify: %s", err)
+	}
+}
+
+func TestCreateSSHSignedTag(t *testing.T) {
+	r, err := testRepo([]testCommit{{msg: "feat: a feature"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New(r, "/tmp/test")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger := &object.Signature{Name: "Jane Doe", Email: "jane@doe.org", When: time.Now()}
+	err = repo.CreateTag("v1.0.0", head, &TagOptions{Message: "signed", Tagger: tagger, SSHSigner: signer}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateTag("v1.0.0", head, &TagOptions{Message: "signed", Tagger: tagger, SSHSigner: signer}, nil); err != git.ErrTagExists {
+		t.Fatalf("expected %s, got %v", git.ErrTagExists, err)
+	}
+
+	tag := testTagObject(t, r, "v1.0.0")
+	if !strings.HasPrefix(tag.PGPSignature, "-----BEGIN SSH SIGNATURE-----\n") {
+		t.Fatalf("expected an ssh signature, got %q", tag.PGPSignature)
+	}
+
+	// verify the signature over the tag without it, as git does
+	unsigned := *tag
+	unsigned.PGPSignature = ""
+	obj := &plumbing.MemoryObject{}
+	if err := unsigned.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := obj.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := io.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := strings.TrimPrefix(tag.PGPSignature, "-----BEGIN SSH SIGNATURE-----\n")
+	b64 = strings.TrimSuffix(b64, "-----END SSH SIGNATURE-----\n")
+	blob, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(b64, "\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sshsig struct {
+		Magic     [6]byte
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}
+	if err := ssh.Unmarshal(blob, &sshsig); err != nil {
+		t.Fatal(err)
+	}
+	if sshsig.Namespace != "git" {
+		t.Errorf("expected namespace git, got %s", sshsig.Namespace)
+	}
+	sig := &ssh.Signature{}
+	if err := ssh.Unmarshal(sshsig.Signature, sig); err != nil {
+		t.Fatal(err)
+	}
+	h := sha512.Sum512(payload)
+	signed := &bytes.Buffer{}
+	signed.WriteString(sshSigMagic)
+	writeSSHString(signed, []byte(sshSigNamespace))
+	writeSSHString(signed, nil)
+	writeSSHString(signed, []byte(sshSigHash))
+	writeSSHString(signed, h[:])
+	if err := signer.PublicKey().Verify(signed.Bytes(), sig); err != nil {
+		t.Fatalf("signature does not verify: %s", err)
+	}
+}
+
+func testTagObject(t *testing.T, r *git.Repository, name string) *object.Tag {
+	t.Helper()
+	ref, err := r.Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := r.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("expected an annotated tag: %s", err)
 	}
+	return tag
 }
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+// LoadPGPKey reads the first private key of the armored or binary OpenPGP key
+// ring at path, decrypting it with passphrase if needed
+func LoadPGPKey(path, passphrase string) (*openpgp.Entity, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("could not read OpenPGP key: %w", err)
+		}
+	}
+	for _, e := range entities {
+		if e.PrivateKey == nil {
+			continue
+		}
+		if e.PrivateKey.Encrypted {
+			if passphrase == "" {
+				return nil, errors.New("OpenPGP key is encrypted, but no passphrase was given")
+			}
+			if err := e.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("could not decrypt OpenPGP key: %w", err)
+			}
+		}
+		return e, nil
+	}
+	return nil, errors.New("no OpenPGP private key found")
+}
+
+// LoadSSHSigner reads the SSH private key at path, decrypting it with
+// passphrase if needed
+func LoadSSHSigner(path, passphrase string) (ssh.Signer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(b, []byte(passphrase))
+	}
+	return ssh.ParsePrivateKey(b)
+}
+
+const (
+	sshSigMagic     = "SSHSIG"
+	sshSigVersion   = 1
+	sshSigNamespace = "git"
+	sshSigHash      = "sha512"
+)
+
+// sshSign creates an armored SSH signature of message, in the format git
+// uses for ssh signed objects, see PROTOCOL.sshsig of OpenSSH
+func sshSign(signer ssh.Signer, message []byte) (string, error) {
+	h := sha512.Sum512(message)
+	signed := &bytes.Buffer{}
+	signed.WriteString(sshSigMagic)
+	writeSSHString(signed, []byte(sshSigNamespace))
+	writeSSHString(signed, nil)
+	writeSSHString(signed, []byte(sshSigHash))
+	writeSSHString(signed, h[:])
+
+	var sig *ssh.Signature
+	var err error
+	// RSA keys must not sign with the default SHA-1 algorithm
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, signed.Bytes(), ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, signed.Bytes())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := &bytes.Buffer{}
+	blob.WriteString(sshSigMagic)
+	_ = binary.Write(blob, binary.BigEndian, uint32(sshSigVersion))
+	writeSSHString(blob, signer.PublicKey().Marshal())
+	writeSSHString(blob, []byte(sshSigNamespace))
+	writeSSHString(blob, nil)
+	writeSSHString(blob, []byte(sshSigHash))
+	writeSSHString(blob, ssh.Marshal(sig))
+
+	enc := base64.StdEncoding.EncodeToString(blob.Bytes())
+	armored := &bytes.Buffer{}
+	armored.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(enc) > 70 {
+		armored.WriteString(enc[:70] + "\n")
+		enc = enc[70:]
+	}
+	armored.WriteString(enc + "\n")
+	armored.WriteString("-----END SSH SIGNATURE-----\n")
+	return armored.String(), nil
+}
+
+func writeSSHString(b *bytes.Buffer, s []byte) {
+	_ = binary.Write(b, binary.BigEndian, uint32(len(s)))
+	b.Write(s)
+}
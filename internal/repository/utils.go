@@ -2,8 +2,15 @@ package repository
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
 // findGitDir recursively searches for a .git directory upwards from the current directory
@@ -27,3 +34,96 @@ func findGitDir(path string) (string, error) {
 
 	return "", fmt.Errorf(".git directory not found")
 }
+
+// touchesPaths reports whether commit c changed a file under any of paths,
+// compared to its first parent
+func touchesPaths(c *object.Commit, paths []string) (bool, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return false, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return false, err
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return false, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		if inPaths(change.From.Name, paths) || inPaths(change.To.Name, paths) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inPaths reports whether the slash separated file name is under any of paths
+func inPaths(name string, paths []string) bool {
+	if name == "" {
+		return false
+	}
+	for _, p := range paths {
+		p = path.Clean(filepath.ToSlash(p))
+		if p == "." || name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstParentIter walks the first parents of a commit until it reaches one
+// that is seen
+type firstParentIter struct {
+	next *object.Commit
+	seen map[plumbing.Hash]bool
+}
+
+func newFirstParentIter(c *object.Commit, seen map[plumbing.Hash]bool) *firstParentIter {
+	return &firstParentIter{next: c, seen: seen}
+}
+
+func (it *firstParentIter) Next() (*object.Commit, error) {
+	c := it.next
+	if c == nil || it.seen[c.Hash] {
+		return nil, io.EOF
+	}
+	it.next = nil
+	if c.NumParents() > 0 {
+		p, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		it.next = p
+	}
+	return c, nil
+}
+
+func (it *firstParentIter) ForEach(cb func(*object.Commit) error) error {
+	for {
+		c, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := cb(c); err != nil {
+			if err == storer.ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (it *firstParentIter) Close() {
+	it.next = nil
+}
@@ -4,32 +4,105 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
 	ErrNotConventionalCommit = errors.New("not a conventional commit message")
 
-	commitPattern   = regexp.MustCompile(`^([\w-]+)(?:\(([^\)]*)\))?(!*)\: (.*)$`)
-	footerPattern   = regexp.MustCompile(`^([\w-]+): (.*)$`)
-	breakingPattern = regexp.MustCompile("BREAKING CHANGES?")
+	commitPattern = regexp.MustCompile(`^([\w-]+)(?:\(([^\)]*)\))?(!*)\: (.*)$`)
+	footerPattern = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[\w-]+)(?:: | #)(.*)$`)
+	revertPattern = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})\b`)
 )
 
+// Footer is a git trailer style footer of a commit message, e.g.
+// "Reviewed-by: Z" or "Refs #123"
+type Footer struct {
+	Token string
+	Value string
+}
+
+// IsBreaking reports whether the footer is a BREAKING CHANGE footer, or its
+// BREAKING-CHANGE synonym
+func (f Footer) IsBreaking() bool {
+	return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+}
+
+// Author is the author of a commit
+type Author struct {
+	Name  string
+	Email string
+}
+
 type Commit struct {
-	Footers     map[string]string
+	Footers     []Footer
 	Type        string
 	Scope       string
 	Description string
 	Body        string
-	Attention   bool
+	// Breaking is the description of the BREAKING CHANGE footers
+	Breaking  string
+	Attention bool
+	// Hash, Author and Date are set for commits read from a repository
+	Hash   string
+	Author Author
+	Date   time.Time
+}
+
+// ShortHash returns the first 7 characters of the hash
+func (c *Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// Footer returns the value of the first footer with the given token, compared
+// case insensitively
+func (c *Commit) Footer(token string) string {
+	for _, f := range c.Footers {
+		if strings.EqualFold(f.Token, token) {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// FooterValues returns the values of all footers with the given token,
+// compared case insensitively
+func (c *Commit) FooterValues(token string) []string {
+	values := []string{}
+	for _, f := range c.Footers {
+		if strings.EqualFold(f.Token, token) {
+			values = append(values, f.Value)
+		}
+	}
+	return values
+}
+
+// IsBreaking reports whether the commit is marked as a breaking change, with
+// a "!" in the header or a BREAKING CHANGE footer
+func (c *Commit) IsBreaking() bool {
+	return c.Attention || c.Breaking != ""
 }
 
 func (c *Commit) BumpKind(cfg *Config) BumpKind {
-	if c.Attention || breakingPattern.MatchString(c.Body) {
+	if c.IsBreaking() {
 		return BumpMajor
 	}
 	return cfg.BumpKind(c.Type)
 }
 
+// RevertedHash returns the hash of the commit reverted by message, from the
+// "This reverts commit <hash>." line git adds to reverts
+func RevertedHash(message string) (string, bool) {
+	found := revertPattern.FindStringSubmatch(message)
+	if found == nil {
+		return "", false
+	}
+	return found[1], true
+}
+
 func ParseCommitMessage(message string) (*Commit, error) {
 	lines := strings.Split(message, "\n")
 
@@ -46,9 +119,28 @@ func ParseCommitMessage(message string) (*Commit, error) {
 		Description: found[0][4],
 	}
 
+	parseBody(c, lines[1:])
+	return c, nil
+}
+
+// parseBody sets the body, footers and breaking change of c from the lines
+// after the header
+func parseBody(c *Commit, lines []string) {
+	bodyLines, footers := parseFooters(lines)
+	if len(footers) > 0 {
+		c.Footers = footers
+	}
+	breaking := []string{}
+	for _, f := range footers {
+		if f.IsBreaking() {
+			breaking = append(breaking, f.Value)
+		}
+	}
+	c.Breaking = strings.Join(breaking, "\n")
+
 	sections := [][]string{}
 	currentSection := []string{}
-	for _, line := range lines[1:] {
+	for _, line := range bodyLines {
 		if line == "" {
 			if len(currentSection) > 0 {
 				sections = append(sections, currentSection)
@@ -58,15 +150,11 @@ func ParseCommitMessage(message string) (*Commit, error) {
 		}
 		currentSection = append(currentSection, line)
 	}
+	if len(currentSection) > 0 {
+		sections = append(sections, currentSection)
+	}
 	body := strings.Builder{}
 	for i, section := range sections {
-		if i == len(sections)-1 {
-			ftr := parseFooter(section)
-			if len(ftr) > 0 {
-				c.Footers = ftr
-				continue
-			}
-		}
 		if i != 0 {
 			body.WriteString("\n")
 		}
@@ -74,18 +162,36 @@ func ParseCommitMessage(message string) (*Commit, error) {
 	}
 	body.WriteString("\n")
 	c.Body = body.String()
-
-	return c, nil
 }
 
-func parseFooter(section []string) map[string]string {
-	footers := map[string]string{}
-	for _, line := range section {
-		found := footerPattern.FindAllStringSubmatch(line, -1)
-		if len(found) < 1 {
-			return nil
+// parseFooters splits the lines after the header into body and footers. The
+// footers start with the last paragraph that starts with a footer token, and
+// lines that do not start with a token continue the value of the previous
+// footer.
+func parseFooters(lines []string) ([]string, []Footer) {
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if footerPattern.MatchString(lines[i]) && (i == 0 || lines[i-1] == "") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return lines, nil
+	}
+
+	footers := []Footer{}
+	for _, line := range lines[start:] {
+		found := footerPattern.FindStringSubmatch(line)
+		if found != nil {
+			footers = append(footers, Footer{Token: found[1], Value: found[2]})
+			continue
 		}
-		footers[found[0][1]] = found[0][2]
+		last := &footers[len(footers)-1]
+		last.Value += "\n" + line
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimRight(footers[i].Value, "\n")
 	}
-	return footers
+	return lines[:start], footers
 }
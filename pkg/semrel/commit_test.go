@@ -45,11 +45,118 @@ line section
 		t.Errorf("expected 2 footers, got %d", len(c.Footers))
 	}
 
-	if c.Footers["Signed-off-by"] != "The Grumpy Lion" {
-		t.Errorf("expected 'Signed-off-by' to be 'The Grumpy Lion', got %s", c.Footers["Signed-off-by"])
+	if c.Footer("Signed-off-by") != "The Grumpy Lion" {
+		t.Errorf("expected 'Signed-off-by' to be 'The Grumpy Lion', got %s", c.Footer("Signed-off-by"))
 	}
 
-	if c.Footers["Some-other-footer"] != "Some value" {
-		t.Errorf("expected 'Some-other-footer' to be 'Some value', got %s", c.Footers["Some-other-footer"])
+	if c.Footer("Some-other-footer") != "Some value" {
+		t.Errorf("expected 'Some-other-footer' to be 'Some value', got %s", c.Footer("Some-other-footer"))
+	}
+}
+
+var commitWithBreakingFooter = `feat(api): remove the v1 endpoints
+
+The v1 endpoints were deprecated a year ago.
+
+Reviewed-by: Z
+Refs #133
+BREAKING CHANGE: the v1 endpoints are gone,
+use the v2 endpoints instead
+Refs #134
+`
+
+func TestBreakingChangeFooter(t *testing.T) {
+	c, err := ParseCommitMessage(commitWithBreakingFooter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.IsBreaking() {
+		t.Error("expected commit to be breaking")
+	}
+	if c.BumpKind(DefaultConfig) != BumpMajor {
+		t.Errorf("expected major bump, got %s", c.BumpKind(DefaultConfig))
+	}
+
+	wantBreaking := "the v1 endpoints are gone,\nuse the v2 endpoints instead"
+	if c.Breaking != wantBreaking {
+		t.Errorf("expected breaking to be %q, got %q", wantBreaking, c.Breaking)
+	}
+
+	wantBody := "The v1 endpoints were deprecated a year ago.\n"
+	if c.Body != wantBody {
+		t.Errorf("expected body to be %q, got %q", wantBody, c.Body)
+	}
+
+	if len(c.Footers) != 4 {
+		t.Fatalf("expected 4 footers, got %d", len(c.Footers))
+	}
+	refs := c.FooterValues("refs")
+	if len(refs) != 2 || refs[0] != "133" || refs[1] != "134" {
+		t.Errorf("expected refs [133 134], got %v", refs)
+	}
+	if c.Footer("Reviewed-by") != "Z" {
+		t.Errorf("expected 'Reviewed-by' to be 'Z', got %s", c.Footer("Reviewed-by"))
+	}
+}
+
+func TestBreakingChangeSynonym(t *testing.T) {
+	c, err := ParseCommitMessage("fix: drop flag\n\nBREAKING-CHANGE: the --old flag is gone\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Breaking != "the --old flag is gone" {
+		t.Errorf("expected breaking description, got %q", c.Breaking)
+	}
+	if c.Body != "\n" {
+		t.Errorf("expected empty body, got %q", c.Body)
+	}
+}
+
+func TestBreakingChangeInProse(t *testing.T) {
+	msg := `docs: explain releases
+
+This section describes how BREAKING CHANGES are released.
+
+Signed-off-by: The Grumpy Lion
+`
+	c, err := ParseCommitMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.IsBreaking() {
+		t.Error("expected commit mentioning breaking changes in the body not to be breaking")
+	}
+	if c.Footer("Signed-off-by") != "The Grumpy Lion" {
+		t.Errorf("expected 'Signed-off-by' footer, got %v", c.Footers)
+	}
+}
+
+func TestAttentionWithoutFooter(t *testing.T) {
+	c, err := ParseCommitMessage("refactor!: drop support for Go 1.20\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsBreaking() || c.Breaking != "" {
+		t.Errorf("expected breaking without description, got %t %q", c.IsBreaking(), c.Breaking)
+	}
+}
+
+func TestRevertedHash(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		message string
+		want    string
+		ok      bool
+	}{
+		{"Revert \"feat: add login\"\n\nThis reverts commit " + hash + ".\n", hash, true},
+		{"revert: feat: add login\n\nThis reverts commit 0123456.\n\nRefs #1\n", "0123456", true},
+		{"feat: add login\n\nThis does not revert commit " + hash + ".\n", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := RevertedHash(tt.message)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: expected %q %t, got %q %t", tt.message, tt.want, tt.ok, got, ok)
+		}
 	}
 }
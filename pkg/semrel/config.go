@@ -2,6 +2,12 @@ package semrel
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
 
 	"github.com/Masterminds/semver/v3"
 	mapset "github.com/deckarep/golang-set/v2"
@@ -16,8 +22,17 @@ var DefaultConfig = &Config{
 	majorTypes:     mapset.NewSet[string](),
 	initialVersion: semver.New(1, 0, 0, "", ""),
 	devMajorBump:   BumpPatch,
+	tagTemplate:    defaultTagTemplate,
+	commitMessage:  defaultCommitMessage,
 }
 
+var defaultTagTemplate = template.Must(template.New("tag").Parse(DefaultTagTemplate))
+
+// DefaultCommitMessage is the message of the commit of the bumped files
+const DefaultCommitMessage = "chore(release): {{ .Tag }} [skip ci]"
+
+var defaultCommitMessage = template.Must(template.New("commit").Parse(DefaultCommitMessage))
+
 func WithDefaultBump(b BumpKind) ConfigOption {
 	return func(c *Config) {
 		c.defaultBump = b
@@ -78,12 +93,114 @@ func WithPushTag() ConfigOption {
 	}
 }
 
+func WithCommitParser(p CommitParser) ConfigOption {
+	return func(c *Config) {
+		c.parser = p
+	}
+}
+
+func WithLint(lint Lint) ConfigOption {
+	return func(c *Config) {
+		c.lint = lint
+	}
+}
+
+func WithBranches(branches ...Branch) ConfigOption {
+	return func(c *Config) {
+		c.branches = append([]Branch(nil), branches...)
+	}
+}
+
+func WithIncludePrereleases() ConfigOption {
+	return func(c *Config) {
+		c.includePrereleases = true
+	}
+}
+
+func WithFirstParent() ConfigOption {
+	return func(c *Config) {
+		c.firstParent = true
+	}
+}
+
+func WithFiles(files ...File) ConfigOption {
+	return func(c *Config) {
+		c.files = append([]File(nil), files...)
+	}
+}
+
+func WithCommitFiles() ConfigOption {
+	return func(c *Config) {
+		c.commitFiles = true
+	}
+}
+
+func WithCommitMessage(tmpl string) ConfigOption {
+	return func(c *Config) {
+		c.commitMessageStr = tmpl
+	}
+}
+
+func WithAnnotateTag() ConfigOption {
+	return func(c *Config) {
+		c.annotateTag = true
+	}
+}
+
+func WithTagMessage(message string) ConfigOption {
+	return func(c *Config) {
+		c.tagMessage = message
+	}
+}
+
+func WithTagger(tagger *Tagger) ConfigOption {
+	return func(c *Config) {
+		c.tagger = tagger
+	}
+}
+
+func WithSigning(signing *Signing) ConfigOption {
+	return func(c *Config) {
+		c.signing = signing
+	}
+}
+
 func WithPlatform(platform string) ConfigOption {
 	return func(c *Config) {
 		c.platform = platform
 	}
 }
 
+func WithPlatformURL(url string) ConfigOption {
+	return func(c *Config) {
+		c.platformURL = url
+	}
+}
+
+func WithDraft() ConfigOption {
+	return func(c *Config) {
+		c.draft = true
+	}
+}
+
+func WithLatest(latest bool) ConfigOption {
+	return func(c *Config) {
+		c.latest = &latest
+	}
+}
+
+func WithOnExisting(policy string) ConfigOption {
+	return func(c *Config) {
+		c.onExisting = policy
+	}
+}
+
+func WithAssets(assets ...Asset) ConfigOption {
+	return func(c *Config) {
+		c.assets = append([]Asset(nil), assets...)
+	}
+}
+
 func WithMaTchRules(rules ...MatchRule) ConfigOption {
 	return func(c *Config) {
 		c.matchRules = rules
@@ -96,20 +213,82 @@ func WithFilters(filters *Filters) ConfigOption {
 	}
 }
 
+func WithNotes(notes *Notes) ConfigOption {
+	return func(c *Config) {
+		c.notes = notes
+	}
+}
+
+func WithPackages(pkgs ...Package) ConfigOption {
+	return func(c *Config) {
+		c.packages = append([]Package(nil), pkgs...)
+	}
+}
+
+func WithTagTemplate(tmpl string) ConfigOption {
+	return func(c *Config) {
+		c.tagTemplateStr = tmpl
+	}
+}
+
+func WithTagPattern(pattern string) ConfigOption {
+	return func(c *Config) {
+		c.tagPatternStr = pattern
+	}
+}
+
+func WithChangelog(changelog *Changelog) ConfigOption {
+	return func(c *Config) {
+		c.changelog = changelog
+	}
+}
+
+func WithLinks(links *Links) ConfigOption {
+	return func(c *Config) {
+		c.links = links
+	}
+}
+
 type Config struct {
-	patchTypes     mapset.Set[string]
-	minorTypes     mapset.Set[string]
-	majorTypes     mapset.Set[string]
-	initialVersion *semver.Version
-	prefix         string
-	defaultBump    BumpKind
-	devMajorBump   BumpKind
-	development    bool
-	createTag      bool
-	pushTag        bool
-	platform       string
-	matchRules     []MatchRule
-	filters        *Filters
+	patchTypes         mapset.Set[string]
+	minorTypes         mapset.Set[string]
+	majorTypes         mapset.Set[string]
+	initialVersion     *semver.Version
+	prefix             string
+	defaultBump        BumpKind
+	devMajorBump       BumpKind
+	development        bool
+	createTag          bool
+	pushTag            bool
+	parser             CommitParser
+	lint               Lint
+	branches           []Branch
+	includePrereleases bool
+	firstParent        bool
+	files              []File
+	commitFiles        bool
+	commitMessageStr   string
+	commitMessage      *template.Template
+	annotateTag        bool
+	tagMessage         string
+	tagger             *Tagger
+	signing            *Signing
+	platform           string
+	platformURL        string
+	assets             []Asset
+	draft              bool
+	latest             *bool
+	onExisting         string
+	matchRules         []MatchRule
+	filters            *Filters
+	notes              *Notes
+	packages           []Package
+	tagTemplateStr     string
+	tagPatternStr      string
+	tagTemplate        *template.Template
+	tagPattern         *template.Template
+	changelog          *Changelog
+	links              *Links
 }
 
 func (c *Config) DefaultBump() BumpKind {
@@ -129,6 +308,13 @@ func (c *Config) BumpKind(str string) BumpKind {
 	return BumpNone
 }
 
+// Types returns the patch, minor and major types
+func (c *Config) Types() []string {
+	types := c.patchTypes.Union(c.minorTypes).Union(c.majorTypes).ToSlice()
+	slices.Sort(types)
+	return types
+}
+
 func (c *Config) InitialVersion() *semver.Version {
 	return c.initialVersion
 }
@@ -149,10 +335,124 @@ func (c *Config) PushTag() bool {
 	return c.pushTag
 }
 
+// CommitParser returns the parser of commit messages, conventional commits
+// by default
+func (c *Config) CommitParser() CommitParser {
+	if c.parser == nil {
+		return ConventionalParser{}
+	}
+	return c.parser
+}
+
+// Lint returns the lint rules, with the default maximum header length if not
+// set
+func (c *Config) Lint() Lint {
+	l := c.lint
+	if l.MaxHeaderLength == 0 {
+		l.MaxHeaderLength = DefaultMaxHeaderLength
+	}
+	return l
+}
+
+func (c *Config) Branches() []Branch {
+	return c.branches
+}
+
+// Branch returns the first configured branch matching name, or nil
+func (c *Config) Branch(name string) *Branch {
+	for i, b := range c.branches {
+		if ok, _ := path.Match(b.Name, name); ok {
+			return &c.branches[i]
+		}
+	}
+	return nil
+}
+
+// Constraint returns the range of b, or nil if it has none
+func (b *Branch) Constraint() (*semver.Constraints, error) {
+	if b.Range == "" {
+		return nil, nil
+	}
+	return semver.NewConstraint(b.Range)
+}
+
+func (c *Config) IncludePrereleases() bool {
+	return c.includePrereleases
+}
+
+func (c *Config) FirstParent() bool {
+	return c.firstParent
+}
+
+// Files returns the files of the package with the given name, or of the
+// repository if empty
+func (c *Config) Files(pkg string) []File {
+	files := []File{}
+	for _, f := range c.files {
+		if f.Package == pkg {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (c *Config) CommitFiles() bool {
+	return c.commitFiles
+}
+
+// CommitMessage renders the message of the commit of the bumped files
+func (c *Config) CommitMessage(data TagData, tag string) (string, error) {
+	b := &strings.Builder{}
+	err := c.commitMessage.Execute(b, struct {
+		TagData
+		Tag string
+	}{data, tag})
+	return b.String(), err
+}
+
+func (c *Config) AnnotateTag() bool {
+	return c.annotateTag
+}
+
+func (c *Config) TagMessage() string {
+	return c.tagMessage
+}
+
+func (c *Config) Tagger() *Tagger {
+	return c.tagger
+}
+
+func (c *Config) Signing() *Signing {
+	return c.signing
+}
+
 func (c *Config) Platform() string {
 	return c.platform
 }
 
+func (c *Config) PlatformURL() string {
+	return c.platformURL
+}
+
+func (c *Config) Assets() []Asset {
+	return c.assets
+}
+
+func (c *Config) Draft() bool {
+	return c.draft
+}
+
+// Latest returns whether releases are marked as the latest, or nil to leave
+// it to the platform
+func (c *Config) Latest() *bool {
+	return c.latest
+}
+
+// OnExisting returns the policy for releases that already exist
+func (c *Config) OnExisting() string {
+	return c.onExisting
+}
+
 func (c *Config) MatchRules() []MatchRule {
 	return c.matchRules
 }
@@ -161,6 +461,66 @@ func (c *Config) Filters() *Filters {
 	return c.filters
 }
 
+func (c *Config) Notes() *Notes {
+	return c.notes
+}
+
+func (c *Config) Packages() []Package {
+	return c.packages
+}
+
+// Package returns the package with the given name.
+func (c *Config) Package(name string) (Package, error) {
+	for _, p := range c.packages {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Package{}, fmt.Errorf("unknown package: %s", name)
+}
+
+func (c *Config) Changelog() *Changelog {
+	return c.changelog
+}
+
+// Links returns the configured URL templates of release notes, nil if none
+func (c *Config) Links() *Links {
+	return c.links
+}
+
+// TagFormat returns the format of the version tags of pkg.
+func (c *Config) TagFormat(pkg Package) (*TagFormat, error) {
+	var pattern *regexp.Regexp
+	if c.tagPattern != nil {
+		var err error
+		if pattern, err = tagPattern(c.tagPattern, pkg); err != nil {
+			return nil, err
+		}
+	}
+	return NewTagFormat(c.tagTemplate, pattern, pkg)
+}
+
+// tagPattern renders the tag pattern template of pkg, with its quoted name
+// and prefix, and compiles it
+func tagPattern(tmpl *template.Template, pkg Package) (*regexp.Regexp, error) {
+	b := strings.Builder{}
+	err := tmpl.Execute(&b, TagData{
+		Prefix:  regexp.QuoteMeta(pkg.Prefix),
+		Package: regexp.QuoteMeta(pkg.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern: %w", err)
+	}
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern: %w", err)
+	}
+	if re.SubexpIndex("version") < 0 {
+		return nil, ErrNoVersionGroup
+	}
+	return re, nil
+}
+
 func NewConfig(opts ...ConfigOption) (*Config, error) {
 	c := &Config{
 		patchTypes: mapset.NewSet[string](),
@@ -175,6 +535,123 @@ func NewConfig(opts ...ConfigOption) (*Config, error) {
 		c.minorTypes.ContainsAny(c.majorTypes.ToSlice()...) {
 		return nil, errors.New("commit types overlap")
 	}
+	names := mapset.NewSet[string]()
+	for i := range c.packages {
+		p := &c.packages[i]
+		if p.Name == "" {
+			return nil, errors.New("package name is required")
+		}
+		if !names.Add(p.Name) {
+			return nil, fmt.Errorf("duplicate package: %s", p.Name)
+		}
+		if p.Path == "" {
+			return nil, fmt.Errorf("package %s: path is required", p.Name)
+		}
+		p.Path = path.Clean(strings.TrimPrefix(p.Path, "/"))
+		if p.Prefix == "" {
+			p.Prefix = p.Name + "/v"
+		}
+	}
+	if c.lint.MaxHeaderLength < 0 {
+		return nil, errors.New("lint: max header length must not be negative")
+	}
+	for _, b := range c.branches {
+		if b.Name == "" {
+			return nil, errors.New("branch name is required")
+		}
+		if _, err := path.Match(b.Name, ""); err != nil {
+			return nil, fmt.Errorf("branch %s: invalid pattern: %w", b.Name, err)
+		}
+		if b.Prerelease != "" {
+			if _, err := semver.NewVersion("0.0.0-" + b.Prerelease); err != nil {
+				return nil, fmt.Errorf("branch %s: invalid prerelease %q", b.Name, b.Prerelease)
+			}
+		}
+		if _, err := b.Constraint(); err != nil {
+			return nil, fmt.Errorf("branch %s: invalid range: %w", b.Name, err)
+		}
+	}
+	for _, f := range c.files {
+		if f.Path == "" {
+			return nil, errors.New("file path is required")
+		}
+		if (f.Key == "") == (f.Pattern == "") {
+			return nil, fmt.Errorf("file %s: either key or pattern is required", f.Path)
+		}
+		if f.Pattern != "" {
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("file %s: invalid pattern: %w", f.Path, err)
+			}
+			if re.NumSubexp() == 0 {
+				return nil, fmt.Errorf("file %s: pattern has no capture group", f.Path)
+			}
+		}
+		if f.Package != "" && !names.Contains(f.Package) {
+			return nil, fmt.Errorf("file %s: unknown package: %s", f.Path, f.Package)
+		}
+	}
+	for _, a := range c.assets {
+		if a.Path == "" {
+			return nil, errors.New("asset path is required")
+		}
+		if _, err := path.Match(a.Path, ""); err != nil {
+			return nil, fmt.Errorf("asset %s: invalid pattern: %w", a.Path, err)
+		}
+	}
+	switch c.onExisting {
+	case "", OnExistingFail, OnExistingSkip, OnExistingUpdate:
+	default:
+		return nil, fmt.Errorf("invalid on existing policy: %s", c.onExisting)
+	}
+	c.commitMessage = defaultCommitMessage
+	if c.commitMessageStr != "" {
+		tmpl, err := template.New("commit").Parse(c.commitMessageStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit message: %w", err)
+		}
+		c.commitMessage = tmpl
+	}
+	c.tagTemplate = defaultTagTemplate
+	if c.tagTemplateStr != "" {
+		tmpl, err := template.New("tag").Parse(c.tagTemplateStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag template: %w", err)
+		}
+		c.tagTemplate = tmpl
+	}
+	if c.tagPatternStr != "" {
+		tmpl, err := template.New("tagPattern").Parse(c.tagPatternStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag pattern: %w", err)
+		}
+		c.tagPattern = tmpl
+	}
+	if _, err := c.TagFormat(Package{Prefix: c.prefix}); err != nil {
+		return nil, err
+	}
+	// a pattern matching the tags of every package would mix their versions
+	patterns := map[string]string{}
+	for _, pkg := range c.packages {
+		f, err := c.TagFormat(pkg)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+		if other, ok := patterns[f.pattern.String()]; ok {
+			return nil, fmt.Errorf("packages %s and %s have the same tag pattern, use .Package or .Prefix in it", other, pkg.Name)
+		}
+		patterns[f.pattern.String()] = pkg.Name
+	}
+	if c.signing != nil {
+		switch c.signing.Format {
+		case "", "openpgp", "ssh":
+		default:
+			return nil, fmt.Errorf("invalid signing format: %s", c.signing.Format)
+		}
+		if c.signing.Key == "" {
+			return nil, errors.New("signing key is required")
+		}
+	}
 	if c.initialVersion == nil {
 		if c.development {
 			c.initialVersion = semver.New(0, 1, 0, "", "")
@@ -230,6 +707,14 @@ func NewConfigFromConfigFile(cf *ConfigFile) (*Config, error) {
 		opts = append(opts, WithPrefix(cf.Prefix))
 	}
 
+	if cf.TagTemplate != "" {
+		opts = append(opts, WithTagTemplate(cf.TagTemplate))
+	}
+
+	if cf.TagPattern != "" {
+		opts = append(opts, WithTagPattern(cf.TagPattern))
+	}
+
 	if cf.CreateTag {
 		opts = append(opts, WithCreateTag())
 	}
@@ -238,10 +723,82 @@ func NewConfigFromConfigFile(cf *ConfigFile) (*Config, error) {
 		opts = append(opts, WithPushTag())
 	}
 
+	if cf.Parser != nil {
+		p, err := NewCommitParser(cf.Parser.Name, cf.Parser.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCommitParser(p))
+	}
+
+	if cf.Lint != nil {
+		opts = append(opts, WithLint(*cf.Lint))
+	}
+
+	if len(cf.Branches) > 0 {
+		opts = append(opts, WithBranches(cf.Branches...))
+	}
+
+	if cf.IncludePrereleases {
+		opts = append(opts, WithIncludePrereleases())
+	}
+
+	if cf.FirstParent {
+		opts = append(opts, WithFirstParent())
+	}
+
+	if len(cf.Files) > 0 {
+		opts = append(opts, WithFiles(cf.Files...))
+	}
+
+	if cf.CommitFiles {
+		opts = append(opts, WithCommitFiles())
+	}
+
+	if cf.CommitMessage != "" {
+		opts = append(opts, WithCommitMessage(cf.CommitMessage))
+	}
+
+	if cf.AnnotateTag {
+		opts = append(opts, WithAnnotateTag())
+	}
+
+	if cf.TagMessage != "" {
+		opts = append(opts, WithTagMessage(cf.TagMessage))
+	}
+
+	if cf.Tagger != nil {
+		opts = append(opts, WithTagger(cf.Tagger))
+	}
+
+	if cf.Signing != nil {
+		opts = append(opts, WithSigning(cf.Signing))
+	}
+
 	if cf.Platform != "" {
 		opts = append(opts, WithPlatform(cf.Platform))
 	}
 
+	if cf.PlatformURL != "" {
+		opts = append(opts, WithPlatformURL(cf.PlatformURL))
+	}
+
+	if len(cf.Assets) > 0 {
+		opts = append(opts, WithAssets(cf.Assets...))
+	}
+
+	if cf.Draft {
+		opts = append(opts, WithDraft())
+	}
+
+	if cf.Latest != nil {
+		opts = append(opts, WithLatest(*cf.Latest))
+	}
+
+	if cf.OnExisting != "" {
+		opts = append(opts, WithOnExisting(cf.OnExisting))
+	}
+
 	if len(cf.MatchRules) > 0 {
 		opts = append(opts, WithMaTchRules(cf.MatchRules...))
 	}
@@ -250,5 +807,21 @@ func NewConfigFromConfigFile(cf *ConfigFile) (*Config, error) {
 		opts = append(opts, WithFilters(cf.Filters))
 	}
 
+	if cf.Notes != nil {
+		opts = append(opts, WithNotes(cf.Notes))
+	}
+
+	if len(cf.Packages) > 0 {
+		opts = append(opts, WithPackages(cf.Packages...))
+	}
+
+	if cf.Changelog != nil {
+		opts = append(opts, WithChangelog(cf.Changelog))
+	}
+
+	if cf.Links != nil {
+		opts = append(opts, WithLinks(cf.Links))
+	}
+
 	return NewConfig(opts...)
 }
@@ -16,6 +16,137 @@ type MatchRule struct {
 	Replace string `yaml:"replace"`
 }
 
+// NoteSection groups the commits of the given types in the release notes
+type NoteSection struct {
+	// Title of the section, e.g. "Features"
+	Title string `yaml:"title" json:"title"`
+
+	// Types are the commit types listed in the section
+	Types []string `yaml:"types" json:"types"`
+}
+
+// Notes configures grouped release notes
+type Notes struct {
+	// Sections in the order they appear. Default is "Features" for feat and "Bug Fixes" for fix
+	Sections []NoteSection `yaml:"sections" json:"sections"`
+
+	// Template is the path of a Go template file for the release notes, relative to the repository root
+	Template string `yaml:"template" json:"template"`
+}
+
+// Tagger is the identity of annotated tags
+type Tagger struct {
+	// Name of the tagger
+	Name string `yaml:"name" json:"name"`
+
+	// Email of the tagger
+	Email string `yaml:"email" json:"email"`
+}
+
+// Signing configures the signing of tags
+type Signing struct {
+	// Format of the key. Default is "openpgp"
+	Format string `yaml:"format" json:"format" enum:"openpgp,ssh" default:"openpgp"`
+
+	// Key is the path of the private key file. The passphrase is read from SEMREL_SIGNING_PASSPHRASE
+	Key string `yaml:"key" json:"key"`
+}
+
+// Parser selects the parser of commit messages
+type Parser struct {
+	// Name of the parser. Default is "conventional"
+	Name string `yaml:"name" json:"name" enum:"conventional,angular,gitmoji,regex" default:"conventional"`
+
+	// Pattern of the regex parser, matching the header with the named groups
+	// type, and optionally scope, description and breaking
+	Pattern string `yaml:"pattern" json:"pattern"`
+}
+
+// Lint configures the rules of the lint command
+type Lint struct {
+	// Types allowed in addition to the patch, minor and major types
+	Types []string `yaml:"types" json:"types"`
+
+	// Scopes allowed. If empty, any scope is allowed
+	Scopes []string `yaml:"scopes" json:"scopes"`
+
+	// MaxHeaderLength is the maximum length of the header. Default is 100
+	MaxHeaderLength int `yaml:"maxHeaderLength" json:"maxHeaderLength" default:"100"`
+}
+
+// File is a project file carrying the version, which is set to the next
+// version on release
+type File struct {
+	// Path of the file, relative to the repository root
+	Path string `yaml:"path" json:"path"`
+
+	// Key is the dotted path of the version in a JSON, YAML or TOML file, e.g. "package.version"
+	Key string `yaml:"key" json:"key"`
+
+	// Pattern is a regular expression whose first capture group, or the one named version, is the version
+	Pattern string `yaml:"pattern" json:"pattern"`
+
+	// Package the file belongs to. If not set, the file belongs to the repository
+	Package string `yaml:"package" json:"package"`
+}
+
+// Asset is a file uploaded with the release
+type Asset struct {
+	// Path is a glob of the files, relative to the repository root, e.g. "dist/*.tar.gz"
+	Path string `yaml:"path" json:"path"`
+
+	// Label is the display name of the files on the release, defaults to the file name
+	Label string `yaml:"label" json:"label"`
+}
+
+// Branch configures the releases of the branches matching its name
+type Branch struct {
+	// Name is a glob pattern of branch names, e.g. "release/*"
+	Name string `yaml:"name" json:"name"`
+
+	// Prerelease is the channel of the branch, e.g. "rc". Its versions are
+	// numbered prereleases of the next version, e.g. 1.3.0-rc.1
+	Prerelease string `yaml:"prerelease" json:"prerelease"`
+
+	// Range restricts the versions of a maintenance branch, e.g. "1.x". The
+	// current version is the highest in range, and a next version out of range
+	// is an error
+	Range string `yaml:"range" json:"range"`
+}
+
+// Package is a path scoped part of the repository that is versioned on its own
+type Package struct {
+	// Name identifies the package, e.g. when selected with --package
+	Name string `yaml:"name" json:"name"`
+
+	// Path is the directory of the package relative to the repository root
+	Path string `yaml:"path" json:"path"`
+
+	// Prefix is the tag prefix of the package versions. Defaults to "<name>/v"
+	Prefix string `yaml:"prefix" json:"prefix"`
+}
+
+// Changelog configures the changelog file written by the changelog command
+type Changelog struct {
+	// Path of the changelog file, relative to the package path. Default is "CHANGELOG.md"
+	Path string `yaml:"path" json:"path" default:"CHANGELOG.md"`
+
+	// Style of the changelog. Default is "keepachangelog"
+	Style string `yaml:"style" json:"style" enum:"keepachangelog,conventional" default:"keepachangelog"`
+
+	// CompareURL is a Go template for the link of each version, with .Previous and .Tag
+	CompareURL string `yaml:"compareURL" json:"compareURL"`
+}
+
+// Links configures the URLs in release notes, for platforms whose URLs are not known
+type Links struct {
+	// CommitURL is a Go template for the link of a commit, with .Hash
+	CommitURL string `yaml:"commitURL" json:"commitURL"`
+
+	// CompareURL is a Go template for the link between versions, with .Previous and .Tag
+	CompareURL string `yaml:"compareURL" json:"compareURL"`
+}
+
 // ConfigFile is the configuration file for the semantic release tool in YAML format
 type ConfigFile struct {
 	// The default bump type if no commit types match. Default is "none"
@@ -39,20 +170,97 @@ type ConfigFile struct {
 	// Prefix is the prefix for the versions
 	Prefix string `yaml:"prefix" json:"prefix"`
 
+	// TagTemplate is a Go template for the tag names, with .Prefix, .Package and .Version. Default is "{{ .Prefix }}{{ .Version }}"
+	TagTemplate string `yaml:"tagTemplate" json:"tagTemplate"`
+
+	// TagPattern is a regex with a "version" named group, matching the tag names. It is a Go template with the quoted .Prefix and .Package, which it must use if packages are configured. If not set, it is derived from TagTemplate
+	TagPattern string `yaml:"tagPattern" json:"tagPattern"`
+
 	// CreateTag if true, creates the next version tag
 	CreateTag bool `yaml:"createTag"`
 
 	// PushTag if true, pushes the next version tag. Requires CreateTag to be true
 	PushTag bool `yaml:"pushTag"`
 
+	// Parser of the commit messages. Messages it cannot parse are skipped
+	Parser *Parser `yaml:"parser" json:"parser"`
+
+	// Lint configures the rules of the lint command
+	Lint *Lint `yaml:"lint" json:"lint"`
+
+	// Branches configures the releases per branch. The first matching branch
+	// applies. If set, only matching branches may release
+	Branches []Branch `yaml:"branches" json:"branches"`
+
+	// IncludePrereleases if true, the current version of the current and
+	// compare commands, and the previous version of the changelog, may be a
+	// prerelease. The next version is always computed from the highest final
+	// version, and from the existing prereleases of the branch channel.
+	IncludePrereleases bool `yaml:"includePrereleases" json:"includePrereleases"`
+
+	// FirstParent if true, only follows the first parent of merge commits when
+	// collecting the commits since the last release, for squash merge workflows
+	FirstParent bool `yaml:"firstParent" json:"firstParent"`
+
+	// Files are bumped to the next version when semrel creates the tag, with
+	// --create-tag. The release command does not bump them, the platform
+	// creates its tag.
+	Files []File `yaml:"files" json:"files"`
+
+	// CommitFiles if true, commits the bumped files before tagging
+	CommitFiles bool `yaml:"commitFiles" json:"commitFiles"`
+
+	// CommitMessage is the template of the message of the files commit, with
+	// .Version, .Tag and .Package. Default is "chore(release): {{ .Tag }} [skip ci]"
+	CommitMessage string `yaml:"commitMessage" json:"commitMessage"`
+
+	// AnnotateTag if true, creates an annotated tag with the release notes as message
+	AnnotateTag bool `yaml:"annotateTag" json:"annotateTag"`
+
+	// TagMessage is the message of annotated tags, instead of the release notes
+	TagMessage string `yaml:"tagMessage" json:"tagMessage"`
+
+	// Tagger of annotated tags. If not set, the user of the git config is used
+	Tagger *Tagger `yaml:"tagger" json:"tagger"`
+
+	// Signing signs the tags, which are then annotated
+	Signing *Signing `yaml:"signing" json:"signing"`
+
 	// Platform that the tool is running on, e.g., "github", "gitlab", etc.
 	Platform string `yaml:"platform"`
 
+	// Assets are uploaded with the release, along with a SHA256SUMS file of their checksums
+	Assets []Asset `yaml:"assets" json:"assets"`
+
+	// Draft if true, creates the platform releases as drafts
+	Draft bool `yaml:"draft" json:"draft"`
+
+	// Latest if false, does not mark the platform releases as the latest. Prereleases never are
+	Latest *bool `yaml:"latest" json:"latest"`
+
+	// OnExisting is what to do if the release already exists: fail, skip it, or update its notes. Default is "fail"
+	OnExisting string `yaml:"onExisting" json:"onExisting" enum:"fail,skip,update" default:"fail"`
+
+	// PlatformURL is the API base URL of self-hosted platforms, e.g. "https://ghe.example.com/api/v3", "https://gitlab.example.com/api/v4" or "https://gitea.example.com"
+	PlatformURL string `yaml:"platformURL" json:"platformURL"`
+
 	// MatchRules are regex rules for matching commit messages and replacing them
 	MatchRules []MatchRule `yaml:"matchRules"`
 
 	// Filters are used to exclude certain commit types and scopes from release notes
 	Filters *Filters `yaml:"filters"`
+
+	// Notes groups the release notes in sections, rendered through a template
+	Notes *Notes `yaml:"notes" json:"notes"`
+
+	// Packages are versioned independently, each from the commits touching its path
+	Packages []Package `yaml:"packages" json:"packages"`
+
+	// Changelog configures the changelog file
+	Changelog *Changelog `yaml:"changelog" json:"changelog"`
+
+	// Links overrides the commit and compare URLs of the platform of the origin remote
+	Links *Links `yaml:"links" json:"links"`
 }
 
 func ConfigFileFromPath(path string) (*ConfigFile, error) {
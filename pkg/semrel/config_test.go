@@ -1,6 +1,10 @@
 package semrel
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
 
 func TestConfigFromFileWithEmptyTypes(t *testing.T) {
 	cnf := `
@@ -31,3 +35,179 @@ minorTypes:
 		t.Errorf("expected no bump for 'breaking', got %s", c.BumpKind("breaking"))
 	}
 }
+
+func TestConfigPackages(t *testing.T) {
+	cnf := `
+packages:
+  - name: api
+    path: services/api/
+  - name: web
+    path: /services/web
+    prefix: web-
+`
+	cf, err := ConfigFileFromBytes([]byte(cnf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewConfigFromConfigFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := c.Package("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Path != "services/api" || api.Prefix != "api/v" {
+		t.Errorf("unexpected api package: %+v", api)
+	}
+
+	web, err := c.Package("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if web.Path != "services/web" || web.Prefix != "web-" {
+		t.Errorf("unexpected web package: %+v", web)
+	}
+
+	if _, err := c.Package("db"); err == nil {
+		t.Error("expected error for unknown package, got nil")
+	}
+}
+
+func TestConfigPackagesInvalid(t *testing.T) {
+	for _, pkgs := range [][]Package{
+		{{Path: "services/api"}},
+		{{Name: "api"}},
+		{{Name: "api", Path: "a"}, {Name: "api", Path: "b"}},
+	} {
+		if _, err := NewConfig(WithPackages(pkgs...)); err == nil {
+			t.Errorf("expected error for %+v, got nil", pkgs)
+		}
+	}
+}
+
+func TestConfigBranches(t *testing.T) {
+	cfg, err := NewConfig(WithBranches(
+		Branch{Name: "next", Prerelease: "beta"},
+		Branch{Name: "release/*", Prerelease: "rc"},
+		Branch{Name: "main"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"next": "beta", "release/1.3": "rc", "main": ""} {
+		b := cfg.Branch(name)
+		if b == nil {
+			t.Fatalf("expected branch for %s", name)
+		}
+		if b.Prerelease != want {
+			t.Errorf("%s: expected prerelease %q, got %q", name, want, b.Prerelease)
+		}
+	}
+	if b := cfg.Branch("feature/x"); b != nil {
+		t.Errorf("expected no branch for feature/x, got %+v", b)
+	}
+
+	for _, branches := range [][]Branch{
+		{{Prerelease: "rc"}},
+		{{Name: "release/[", Prerelease: "rc"}},
+		{{Name: "next", Prerelease: "beta!"}},
+		{{Name: "1.x", Range: "one"}},
+	} {
+		if _, err := NewConfig(WithBranches(branches...)); err == nil {
+			t.Errorf("expected error for %+v, got nil", branches)
+		}
+	}
+}
+
+func TestBranchConstraint(t *testing.T) {
+	b := &Branch{Name: "1.x", Range: "1.x"}
+	c, err := b.Constraint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v, want := range map[string]bool{"1.0.0": true, "1.9.3": true, "2.0.0": false, "0.9.0": false} {
+		if got := c.Check(semver.MustParse(v)); got != want {
+			t.Errorf("%s: expected %t, got %t", v, want, got)
+		}
+	}
+	if c, err := (&Branch{Name: "main"}).Constraint(); c != nil || err != nil {
+		t.Errorf("expected no constraint, got %v, %v", c, err)
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	cfg, err := NewConfig(
+		WithPackages(Package{Name: "api", Path: "services/api"}),
+		WithFiles(
+			File{Path: "package.json", Key: "version"},
+			File{Path: "services/api/version.go", Pattern: `Version = "(.*)"`, Package: "api"},
+		),
+		WithCommitMessage("release {{ .Package }} {{ .Version }} as {{ .Tag }}"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := cfg.Files(""); len(files) != 1 || files[0].Path != "package.json" {
+		t.Errorf("expected the repository files, got %+v", files)
+	}
+	if files := cfg.Files("api"); len(files) != 1 || files[0].Package != "api" {
+		t.Errorf("expected the api files, got %+v", files)
+	}
+	msg, err := cfg.CommitMessage(TagData{Package: "api", Version: "1.2.3"}, "api/v1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "release api 1.2.3 as api/v1.2.3" {
+		t.Errorf("unexpected commit message %q", msg)
+	}
+	msg, err = DefaultConfig.CommitMessage(TagData{Version: "1.2.3"}, "v1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "chore(release): v1.2.3 [skip ci]" {
+		t.Errorf("unexpected default commit message %q", msg)
+	}
+
+	for _, files := range [][]File{
+		{{Key: "version"}},
+		{{Path: "package.json"}},
+		{{Path: "package.json", Key: "version", Pattern: "(.*)"}},
+		{{Path: "version.go", Pattern: "Version"}},
+		{{Path: "version.go", Pattern: "(.*)", Package: "web"}},
+	} {
+		if _, err := NewConfig(WithFiles(files...)); err == nil {
+			t.Errorf("expected error for %+v, got nil", files)
+		}
+	}
+}
+
+func TestConfigAssets(t *testing.T) {
+	cfg, err := NewConfig(WithAssets(Asset{Path: "dist/*.tar.gz", Label: "Binaries"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assets := cfg.Assets(); len(assets) != 1 || assets[0].Label != "Binaries" {
+		t.Errorf("unexpected assets %+v", assets)
+	}
+	for _, asset := range []Asset{{Label: "no path"}, {Path: "dist/[.zip"}} {
+		if _, err := NewConfig(WithAssets(asset)); err == nil {
+			t.Errorf("expected error for %+v, got nil", asset)
+		}
+	}
+}
+
+func TestConfigOnExisting(t *testing.T) {
+	cfg, err := NewConfig(WithOnExisting(OnExistingUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.OnExisting() != OnExistingUpdate {
+		t.Errorf("expected %s, got %s", OnExistingUpdate, cfg.OnExisting())
+	}
+	if _, err := NewConfig(WithOnExisting("replace")); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,95 @@
+package semrel
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxHeaderLength is the maximum length of commit headers
+const DefaultMaxHeaderLength = 100
+
+var (
+	// footerLikePattern matches lines that are meant to be footers, including
+	// the ones whose token is invalid, e.g. "Signed off by: X"
+	footerLikePattern = regexp.MustCompile(`^[A-Za-z][\w -]*: \S`)
+	// breakingFooterPattern matches BREAKING CHANGE tokens in any case and
+	// separator, to report the ones not spelled as the spec requires
+	breakingFooterPattern = regexp.MustCompile(`(?i)^breaking[ -]?changes?$`)
+)
+
+// LintCommitMessage checks message against the commit parser and lint rules of cfg. It
+// returns a problem per violated rule, or nil if message is valid.
+func LintCommitMessage(message string, cfg *Config) []string {
+	header, _, _ := strings.Cut(message, "\n")
+	c, err := cfg.CommitParser().Parse(message)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid header %q: %s", header, err)}
+	}
+
+	problems := []string{}
+	rules := cfg.Lint()
+	if n := len([]rune(header)); n > rules.MaxHeaderLength {
+		problems = append(problems, fmt.Sprintf("header is %d characters long, the maximum is %d", n, rules.MaxHeaderLength))
+	}
+	types := cfg.Types()
+	types = append(types, rules.Types...)
+	if !slices.Contains(types, c.Type) {
+		slices.Sort(types)
+		problems = append(problems, fmt.Sprintf("type %q is not allowed, expected one of %s", c.Type, strings.Join(types, ", ")))
+	}
+	if c.Scope != "" && len(rules.Scopes) > 0 && !slices.Contains(rules.Scopes, c.Scope) {
+		problems = append(problems, fmt.Sprintf("scope %q is not allowed, expected one of %s", c.Scope, strings.Join(rules.Scopes, ", ")))
+	}
+	if strings.TrimSpace(c.Description) == "" {
+		problems = append(problems, "description is empty")
+	}
+	problems = append(problems, lintFooters(message)...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// lintFooters reports footers that do not follow the spec, in the last
+// paragraph if it looks like a block of footers. Tokens with spaces are only
+// reported next to valid footers, as they are ambiguous with prose.
+func lintFooters(message string) []string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	problems := []string{}
+	_, footers := parseFooters(lines[1:])
+	for _, f := range footers {
+		if strings.TrimSpace(f.Value) == "" {
+			problems = append(problems, fmt.Sprintf("footer %q has no value", f.Token))
+		}
+	}
+
+	start := len(lines)
+	for start > 1 && lines[start-1] != "" {
+		start--
+	}
+	if start <= 1 {
+		return problems
+	}
+	block := lines[start:]
+	hasFooter := false
+	for _, line := range block {
+		if !footerLikePattern.MatchString(line) {
+			return problems
+		}
+		hasFooter = hasFooter || footerPattern.MatchString(line)
+	}
+	for _, line := range block {
+		token, _, _ := strings.Cut(line, ":")
+		switch {
+		case breakingFooterPattern.MatchString(token):
+			if token != "BREAKING CHANGE" && token != "BREAKING-CHANGE" {
+				problems = append(problems, fmt.Sprintf("footer %q must be written as \"BREAKING CHANGE: <description>\"", line))
+			}
+		case hasFooter && strings.Contains(token, " "):
+			problems = append(problems, fmt.Sprintf("footer token %q must not contain spaces, use %q", token, strings.ReplaceAll(token, " ", "-")))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,47 @@
+package semrel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintCommitMessage(t *testing.T) {
+	cfg, err := NewConfig(
+		WithPatchTypes("fix"),
+		WithMinorTypes("feat"),
+		WithLint(Lint{Types: []string{"docs", "chore"}, Scopes: []string{"api", "cli"}, MaxHeaderLength: 40}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"valid", "feat(api): add login\n", nil},
+		{"extra type", "docs: describe login\n", nil},
+		{"valid footers", "fix: handle nil\n\nSome body.\n\nBREAKING CHANGE: nil is an error\nRefs #12\n", nil},
+		{"body prose", "fix: handle nil\n\nNote that this: is prose\n", nil},
+		{"not conventional", "add login\n", []string{"invalid header"}},
+		{"type", "style: format\n", []string{`type "style" is not allowed`}},
+		{"scope", "feat(web): add login\n", []string{`scope "web" is not allowed`}},
+		{"header length", "feat: " + strings.Repeat("x", 40) + "\n", []string{"header is 46 characters long"}},
+		{"breaking case", "feat: drop v1\n\nBreaking change: v1 is gone\n", []string{`must be written as "BREAKING CHANGE`}},
+		{"spaced token", "fix: handle nil\n\nSigned off by: Jane\nRefs: #12\n", []string{`footer token "Signed off by" must not contain spaces`}},
+		{"empty footer", "fix: handle nil\n\nReviewed-by: \n", []string{`footer "Reviewed-by" has no value`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LintCommitMessage(tt.message, cfg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d problems, got %q", len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("expected problem containing %q, got %q", want, got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package semrel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CommitParser parses commit messages. It returns ErrNotConventionalCommit
+// for messages not in its format, which are then skipped.
+type CommitParser interface {
+	Parse(message string) (*Commit, error)
+}
+
+const (
+	ParserConventional = "conventional"
+	ParserAngular      = "angular"
+	ParserGitmoji      = "gitmoji"
+	ParserRegex        = "regex"
+)
+
+// NewCommitParser returns the built-in parser with the given name. The regex
+// parser requires a pattern.
+func NewCommitParser(name, pattern string) (CommitParser, error) {
+	switch name {
+	case "", ParserConventional:
+		return ConventionalParser{}, nil
+	case ParserAngular:
+		return AngularParser{}, nil
+	case ParserGitmoji:
+		return GitmojiParser{}, nil
+	case ParserRegex:
+		return NewRegexParser(pattern)
+	default:
+		return nil, fmt.Errorf("invalid commit parser: %s", name)
+	}
+}
+
+// ConventionalParser parses Conventional Commits, see
+// https://www.conventionalcommits.org
+type ConventionalParser struct{}
+
+func (ConventionalParser) Parse(message string) (*Commit, error) {
+	return ParseCommitMessage(message)
+}
+
+var angularPattern = regexp.MustCompile(`^(?:\[([^\]]+)\]\s*)?(build|chore|ci|docs|feat|fix|perf|refactor|revert|style|test)(?:\(([^\)]*)\))?: (.*)$`)
+
+// AngularParser parses the Angular commit message format, optionally prefixed
+// with a ticket, e.g. "[ABC-123] fix(core): description". The ticket is
+// added as a Refs footer. Breaking changes are only marked by footers.
+type AngularParser struct{}
+
+func (AngularParser) Parse(message string) (*Commit, error) {
+	lines := strings.Split(message, "\n")
+	found := angularPattern.FindStringSubmatch(lines[0])
+	if found == nil {
+		return nil, ErrNotConventionalCommit
+	}
+	c := &Commit{
+		Type:        found[2],
+		Scope:       found[3],
+		Description: found[4],
+	}
+	parseBody(c, lines[1:])
+	if found[1] != "" {
+		c.Footers = append([]Footer{{Token: "Refs", Value: found[1]}}, c.Footers...)
+	}
+	return c, nil
+}
+
+// gitmojis maps the shortcodes of https://gitmoji.dev to commit types
+var gitmojis = map[string]string{
+	"sparkles":            "feat",
+	"boom":                "feat",
+	"bug":                 "fix",
+	"ambulance":           "fix",
+	"lock":                "fix",
+	"adhesive_bandage":    "fix",
+	"pencil2":             "fix",
+	"zap":                 "perf",
+	"memo":                "docs",
+	"recycle":             "refactor",
+	"truck":               "refactor",
+	"art":                 "style",
+	"lipstick":            "style",
+	"white_check_mark":    "test",
+	"construction_worker": "ci",
+	"green_heart":         "ci",
+	"arrow_up":            "build",
+	"arrow_down":          "build",
+	"heavy_plus_sign":     "build",
+	"heavy_minus_sign":    "build",
+	"wrench":              "chore",
+	"fire":                "chore",
+	"bookmark":            "chore",
+	"rocket":              "chore",
+	"rewind":              "revert",
+}
+
+// gitmojiEmojis maps the emojis to their shortcodes
+var gitmojiEmojis = map[string]string{
+	"✨": "sparkles",
+	"💥": "boom",
+	"🐛": "bug",
+	"🚑": "ambulance",
+	"🔒": "lock",
+	"🩹": "adhesive_bandage",
+	"✏": "pencil2",
+	"⚡": "zap",
+	"📝": "memo",
+	"♻": "recycle",
+	"🚚": "truck",
+	"🎨": "art",
+	"💄": "lipstick",
+	"✅": "white_check_mark",
+	"👷": "construction_worker",
+	"💚": "green_heart",
+	"⬆": "arrow_up",
+	"⬇": "arrow_down",
+	"➕": "heavy_plus_sign",
+	"➖": "heavy_minus_sign",
+	"🔧": "wrench",
+	"🔥": "fire",
+	"🔖": "bookmark",
+	"🚀": "rocket",
+	"⏪": "rewind",
+}
+
+var gitmojiPattern = regexp.MustCompile(`^(:[\w+-]+:|\S+)\s+(?:\(([^\)]*)\):?\s+)?(.*)$`)
+
+// GitmojiParser parses gitmoji commit messages, e.g. ":sparkles: (api) add
+// login" or "✨ add login". Known gitmojis map to the conventional types,
+// e.g. sparkles to feat, others are their shortcode. The boom gitmoji marks
+// a breaking change.
+type GitmojiParser struct{}
+
+func (GitmojiParser) Parse(message string) (*Commit, error) {
+	lines := strings.Split(message, "\n")
+	found := gitmojiPattern.FindStringSubmatch(lines[0])
+	if found == nil {
+		return nil, ErrNotConventionalCommit
+	}
+	code := strings.Trim(found[1], ":")
+	if !strings.HasPrefix(found[1], ":") {
+		var ok bool
+		code, ok = gitmojiEmojis[strings.TrimSuffix(found[1], "\ufe0f")]
+		if !ok {
+			return nil, ErrNotConventionalCommit
+		}
+	}
+	typ, ok := gitmojis[code]
+	if !ok {
+		typ = code
+	}
+	c := &Commit{
+		Type:        typ,
+		Scope:       found[2],
+		Description: found[3],
+		Attention:   code == "boom",
+	}
+	parseBody(c, lines[1:])
+	return c, nil
+}
+
+// RegexParser parses the header of commit messages with a regular expression.
+// Its named groups are type, which is required, scope, description and
+// breaking, which marks a breaking change if not empty. Without a description
+// group, the header is the description.
+type RegexParser struct {
+	pattern *regexp.Regexp
+}
+
+func NewRegexParser(pattern string) (*RegexParser, error) {
+	if pattern == "" {
+		return nil, errors.New("regex commit parser requires a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit pattern: %w", err)
+	}
+	if re.SubexpIndex("type") < 0 {
+		return nil, errors.New("commit pattern has no type group")
+	}
+	return &RegexParser{pattern: re}, nil
+}
+
+func (p *RegexParser) Parse(message string) (*Commit, error) {
+	lines := strings.Split(message, "\n")
+	found := p.pattern.FindStringSubmatch(lines[0])
+	if found == nil {
+		return nil, ErrNotConventionalCommit
+	}
+	group := func(name string) string {
+		if i := p.pattern.SubexpIndex(name); i >= 0 {
+			return found[i]
+		}
+		return ""
+	}
+	c := &Commit{
+		Type:        strings.ToLower(group("type")),
+		Scope:       group("scope"),
+		Description: group("description"),
+		Attention:   group("breaking") != "",
+	}
+	if p.pattern.SubexpIndex("description") < 0 {
+		c.Description = lines[0]
+	}
+	parseBody(c, lines[1:])
+	return c, nil
+}
@@ -0,0 +1,132 @@
+package semrel
+
+import "testing"
+
+func TestParsers(t *testing.T) {
+	regex, err := NewRegexParser(`^(?P<type>[A-Z]+)(?P<breaking>!?) (?P<scope>\w+)/ (?P<description>.*)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		parser  CommitParser
+		message string
+		want    Commit
+		refs    string
+	}{
+		{
+			name:    "conventional",
+			parser:  ConventionalParser{},
+			message: "feat(api)!: add login",
+			want:    Commit{Type: "feat", Scope: "api", Description: "add login", Attention: true},
+		},
+		{
+			name:    "angular",
+			parser:  AngularParser{},
+			message: "fix(core): handle nil",
+			want:    Commit{Type: "fix", Scope: "core", Description: "handle nil"},
+		},
+		{
+			name:    "angular ticket",
+			parser:  AngularParser{},
+			message: "[ABC-123] feat: add export\n\nBREAKING CHANGE: the format changed\n",
+			want:    Commit{Type: "feat", Description: "add export", Breaking: "the format changed"},
+			refs:    "ABC-123",
+		},
+		{
+			name:    "gitmoji shortcode",
+			parser:  GitmojiParser{},
+			message: ":sparkles: (api) add login",
+			want:    Commit{Type: "feat", Scope: "api", Description: "add login"},
+		},
+		{
+			name:    "gitmoji emoji",
+			parser:  GitmojiParser{},
+			message: "🐛 handle nil",
+			want:    Commit{Type: "fix", Description: "handle nil"},
+		},
+		{
+			name:    "gitmoji variation selector",
+			parser:  GitmojiParser{},
+			message: "⚡️ faster startup",
+			want:    Commit{Type: "perf", Description: "faster startup"},
+		},
+		{
+			name:    "gitmoji breaking",
+			parser:  GitmojiParser{},
+			message: ":boom: drop v1 api",
+			want:    Commit{Type: "feat", Description: "drop v1 api", Attention: true},
+		},
+		{
+			name:    "gitmoji unknown shortcode",
+			parser:  GitmojiParser{},
+			message: ":tada: initial commit",
+			want:    Commit{Type: "tada", Description: "initial commit"},
+		},
+		{
+			name:    "regex",
+			parser:  regex,
+			message: "FEAT! api/ add login",
+			want:    Commit{Type: "feat", Scope: "api", Description: "add login", Attention: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.parser.Parse(tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Type != tt.want.Type || c.Scope != tt.want.Scope || c.Description != tt.want.Description ||
+				c.Attention != tt.want.Attention || c.Breaking != tt.want.Breaking {
+				t.Errorf("expected %+v, got %+v", tt.want, *c)
+			}
+			if refs := c.Footer("Refs"); refs != tt.refs {
+				t.Errorf("expected refs %q, got %q", tt.refs, refs)
+			}
+		})
+	}
+}
+
+func TestParsersNotParsable(t *testing.T) {
+	regex, err := NewRegexParser(`^(?P<type>[A-Z]+): `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		parser  CommitParser
+		message string
+	}{
+		{"angular unknown type", AngularParser{}, "feature: add login"},
+		{"angular attention", AngularParser{}, "feat!: add login"},
+		{"gitmoji plain", GitmojiParser{}, "add login"},
+		{"gitmoji conventional", GitmojiParser{}, "feat: add login"},
+		{"regex", regex, "feat: add login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parser.Parse(tt.message); err != ErrNotConventionalCommit {
+				t.Errorf("expected %v, got %v", ErrNotConventionalCommit, err)
+			}
+		})
+	}
+}
+
+func TestNewCommitParser(t *testing.T) {
+	for _, name := range []string{"", ParserConventional, ParserAngular, ParserGitmoji} {
+		if _, err := NewCommitParser(name, ""); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := NewCommitParser(ParserRegex, `^(?P<type>\w+): `); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, pattern := range []string{"", `^(\w+): `, `^(?P<type>\w+`} {
+		if _, err := NewCommitParser(ParserRegex, pattern); err == nil {
+			t.Errorf("expected error for pattern %q, got nil", pattern)
+		}
+	}
+	if _, err := NewCommitParser("svn", ""); err == nil {
+		t.Error("expected error for unknown parser, got nil")
+	}
+}
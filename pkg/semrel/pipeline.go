@@ -0,0 +1,288 @@
+package semrel
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Release is a released version and the commit its tag points to
+type Release struct {
+	Version *semver.Version
+	Tag     string
+	Commit  string
+}
+
+// Change is a commit of the history. Commit is nil if the message is not
+// parsable, Reverted is set if it is reverted by a later commit.
+type Change struct {
+	Hash     string
+	Subject  string
+	Commit   *Commit
+	Reverted bool
+}
+
+// VersionSource lists the released versions of a package
+type VersionSource interface {
+	// Versions returns the releases tagged in format, in descending order
+	Versions(format *TagFormat) ([]Release, error)
+}
+
+// CommitSource reads the history of a package
+type CommitSource interface {
+	// Commits returns the changes of pkg since the commit since, newest first
+	Commits(pkg Package, since string) ([]Change, error)
+}
+
+// TagCreator creates the tag of a plan
+type TagCreator interface {
+	CreateTag(plan *Plan) error
+}
+
+// Policies for releases that already exist
+const (
+	OnExistingFail   = "fail"
+	OnExistingSkip   = "skip"
+	OnExistingUpdate = "update"
+)
+
+// ReleaseOptions are the options of a platform release
+type ReleaseOptions struct {
+	// Assets are the files uploaded with the release
+	Assets []Asset
+	// Draft releases are not published
+	Draft bool
+	// Prerelease marks the release as not ready for production
+	Prerelease bool
+	// Latest marks the release as the latest or not, if set. Otherwise the
+	// platform decides
+	Latest *bool
+	// OnExisting is the policy if the release of the tag exists: fail, the
+	// default, skip it, or update its notes and upload the assets it misses
+	OnExisting string
+	// Target is the commit the tag is created at and must resolve to. If
+	// empty, the tag is created at the tip of the branch of the releaser.
+	Target string
+}
+
+// Releaser publishes the release of a tag
+type Releaser interface {
+	// Release creates the release of tag and returns its URL
+	Release(tag, notes string, opts *ReleaseOptions) (string, error)
+}
+
+// Pipeline computes the next version of packages and releases it. Only the
+// configuration and the sources are required, without a tag creator or a
+// releaser these steps are skipped.
+type Pipeline struct {
+	Config   *Config
+	Versions VersionSource
+	Commits  CommitSource
+	Tagger   TagCreator
+	Releaser Releaser
+	// ReleaseOptions of the release, which is a prerelease as well if the
+	// next version is
+	ReleaseOptions ReleaseOptions
+	// Notes renders the release notes of a plan, once its versions and
+	// commits are known
+	Notes func(plan *Plan) (string, error)
+}
+
+// PlanOptions are the options of a plan, all optional
+type PlanOptions struct {
+	// Branch is the branch released from, its range limits the current and
+	// next version and its prerelease is the channel to release in
+	Branch *Branch
+	// Build is the build metadata of the next version
+	Build string
+}
+
+// Plan is the computed release of a package
+type Plan struct {
+	Package Package
+	Format  *TagFormat
+	// Current is the highest final version in range, 0.0.0 if none. It is
+	// never a prerelease, regardless of Config.IncludePrereleases, unless an
+	// existing prerelease of the channel has no commits since.
+	Current *semver.Version
+	// CurrentTag is the tag of the current version as found, empty if none
+	CurrentTag string
+	// Released is the release of the current version, nil if none
+	Released *Release
+	Next     semver.Version
+	NextTag  string
+	// Changes are the commits since the current release, newest first
+	Changes []Change
+	// Commits are the parsed and not reverted changes
+	Commits  []*Commit
+	Decision *Decision
+	// Channel is the prerelease channel the next version is released in
+	Channel string
+	// Prerelease is the highest existing prerelease of the next version in
+	// the channel
+	Prerelease *Release
+	// Constraint is the range of the branch
+	Constraint *semver.Constraints
+	Notes      string
+}
+
+// Needed reports whether there is a version to release
+func (p *Plan) Needed() bool {
+	return !p.Next.Equal(p.Current)
+}
+
+var emptyVersion = semver.New(0, 0, 0, "", "")
+
+// Plan finds the current version of pkg and computes the next one from the
+// commits since, as a numbered prerelease if the branch has a channel
+func (p *Pipeline) Plan(pkg Package, opts *PlanOptions) (*Plan, error) {
+	if opts == nil {
+		opts = &PlanOptions{}
+	}
+	branch := opts.Branch
+	if branch == nil {
+		branch = &Branch{}
+	}
+	format, err := p.Config.TagFormat(pkg)
+	if err != nil {
+		return nil, err
+	}
+	constraint, err := branch.Constraint()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := p.Versions.Versions(format)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{
+		Package:    pkg,
+		Format:     format,
+		Current:    emptyVersion,
+		Commits:    []*Commit{},
+		Constraint: constraint,
+	}
+	if p.Config.InitialVersion() != nil {
+		plan.Next = *p.Config.InitialVersion()
+	}
+
+	for i, v := range versions {
+		if v.Version.Prerelease() == "" && (constraint == nil || constraint.Check(v.Version)) {
+			plan.Current = v.Version
+			plan.Released = &versions[i]
+			break
+		}
+	}
+
+	if !plan.Current.Equal(emptyVersion) {
+		plan.Changes, err = p.Commits.Commits(pkg, plan.Released.Commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range plan.Changes {
+			if c.Commit != nil && !c.Reverted {
+				plan.Commits = append(plan.Commits, c.Commit)
+			}
+		}
+		plan.Decision = Decide(plan.Current, plan.Commits, p.Config)
+		plan.Next = plan.Decision.Next
+	}
+
+	if constraint != nil && plan.Needed() && !constraint.Check(&plan.Next) {
+		bump := BumpNone
+		if plan.Decision != nil {
+			bump = plan.Decision.Applied
+		}
+		return nil, fmt.Errorf("next version %s is out of range %s of branch %s, a %s bump cannot be released from it", plan.Next.String(), branch.Range, branch.Name, bump)
+	}
+
+	if branch.Prerelease != "" && plan.Needed() {
+		if err := p.applyChannel(plan, branch.Prerelease, versions); err != nil {
+			return nil, err
+		}
+	}
+
+	// the tags found, which may differ from the configured format
+	switch {
+	case plan.Prerelease != nil && plan.Current == plan.Prerelease.Version:
+		plan.CurrentTag = plan.Prerelease.Tag
+	case plan.Released != nil:
+		plan.CurrentTag = plan.Released.Tag
+	}
+	if !plan.Needed() {
+		plan.NextTag = plan.CurrentTag
+		if plan.NextTag == "" {
+			plan.NextTag, err = format.Tag(&plan.Next)
+		}
+		return plan, err
+	}
+
+	if opts.Build != "" {
+		if plan.Next, err = plan.Next.SetMetadata(opts.Build); err != nil {
+			return nil, err
+		}
+	}
+	if plan.NextTag, err = format.Tag(&plan.Next); err != nil {
+		return nil, err
+	}
+
+	if p.Notes != nil {
+		if plan.Notes, err = p.Notes(plan); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// applyChannel turns the next version into the next numbered prerelease in
+// channel. If the highest existing one has no commits since, it is current.
+func (p *Pipeline) applyChannel(plan *Plan, channel string, versions []Release) error {
+	plan.Channel = channel
+	existing := []*semver.Version{}
+	for i, v := range versions {
+		if _, ok := PrereleaseCounter(v.Version, &plan.Next, channel); !ok {
+			continue
+		}
+		existing = append(existing, v.Version)
+		if plan.Prerelease == nil || plan.Prerelease.Version.LessThan(v.Version) {
+			plan.Prerelease = &versions[i]
+		}
+	}
+	if plan.Prerelease != nil {
+		since, err := p.Commits.Commits(plan.Package, plan.Prerelease.Commit)
+		if err != nil {
+			return err
+		}
+		if len(since) == 0 {
+			plan.Current = plan.Prerelease.Version
+			plan.Next = *plan.Prerelease.Version
+			return nil
+		}
+	}
+	var err error
+	plan.Next, err = NextPrerelease(&plan.Next, channel, existing)
+	return err
+}
+
+// Publish creates the tag of plan and its release, if there is a version to
+// release. It returns the URL of the release, if any.
+func (p *Pipeline) Publish(plan *Plan) (string, error) {
+	if !plan.Needed() {
+		return "", nil
+	}
+	if p.Tagger != nil {
+		if err := p.Tagger.CreateTag(plan); err != nil {
+			return "", err
+		}
+	}
+	if p.Releaser == nil {
+		return "", nil
+	}
+	opts := p.ReleaseOptions
+	opts.Prerelease = opts.Prerelease || plan.Next.Prerelease() != ""
+	url, err := p.Releaser.Release(plan.NextTag, plan.Notes, &opts)
+	if err != nil {
+		return "", fmt.Errorf("could not create release for next %q (current %q): %w", plan.NextTag, plan.Current.String(), err)
+	}
+	return url, nil
+}
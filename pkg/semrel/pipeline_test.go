@@ -0,0 +1,308 @@
+package semrel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type fakeVersions []Release
+
+func (f fakeVersions) Versions(format *TagFormat) ([]Release, error) {
+	return f, nil
+}
+
+// fakeCommits are the changes since each commit
+type fakeCommits map[string][]Change
+
+func (f fakeCommits) Commits(pkg Package, since string) ([]Change, error) {
+	return f[since], nil
+}
+
+type fakeTagger struct {
+	tags []string
+}
+
+func (f *fakeTagger) CreateTag(plan *Plan) error {
+	f.tags = append(f.tags, plan.NextTag)
+	return nil
+}
+
+type fakeReleaser struct {
+	tag   string
+	notes string
+	opts  *ReleaseOptions
+	err   error
+}
+
+func (f *fakeReleaser) Release(tag, notes string, opts *ReleaseOptions) (string, error) {
+	f.tag, f.notes, f.opts = tag, notes, opts
+	return "https://example.com/releases/" + tag, f.err
+}
+
+func release(version, commit string) Release {
+	v := semver.MustParse(version)
+	return Release{Version: v, Tag: "v" + v.String(), Commit: commit}
+}
+
+func change(hash, message string) Change {
+	c, _ := ParseCommitMessage(message)
+	return Change{Hash: hash, Subject: message, Commit: c}
+}
+
+func TestPipelinePlan(t *testing.T) {
+	initial, err := NewConfig(WithInitialVersion(semver.New(0, 1, 0, "", "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := fakeVersions{
+		release("1.3.0-rc.1", "c3"),
+		release("1.2.0", "c2"),
+		release("1.1.0", "c1"),
+	}
+	commits := fakeCommits{
+		"c3": {change("d", "fix: d")},
+		"c2": {change("d", "fix: d"), change("c", "feat: c"), {Hash: "b", Subject: "wip"}},
+		"c1": {change("a", "feat!: a")},
+		"c0": {change("e", "fix: e")},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		versions fakeVersions
+		opts     *PlanOptions
+		current  string
+		next     string
+		nextTag  string
+		commits  int
+		wantErr  bool
+	}{
+		{
+			name:    "initial",
+			cfg:     initial,
+			current: "0.0.0",
+			next:    "0.1.0",
+			nextTag: "v0.1.0",
+		},
+		{
+			name:     "nothing to release",
+			cfg:      DefaultConfig,
+			versions: fakeVersions{release("1.0.0", "none")},
+			current:  "1.0.0",
+			next:     "1.0.0",
+			nextTag:  "v1.0.0",
+		},
+		{
+			name:     "next",
+			cfg:      DefaultConfig,
+			versions: versions,
+			current:  "1.2.0",
+			next:     "1.3.0",
+			nextTag:  "v1.3.0",
+			commits:  2,
+		},
+		{
+			name:     "build",
+			cfg:      DefaultConfig,
+			versions: versions,
+			opts:     &PlanOptions{Build: "42"},
+			current:  "1.2.0",
+			next:     "1.3.0+42",
+			nextTag:  "v1.3.0+42",
+			commits:  2,
+		},
+		{
+			name:     "out of range",
+			cfg:      DefaultConfig,
+			versions: versions,
+			opts:     &PlanOptions{Branch: &Branch{Name: "1.1.x", Range: "1.1.x"}},
+			wantErr:  true,
+		},
+		{
+			name:     "maintenance range",
+			cfg:      DefaultConfig,
+			versions: fakeVersions{release("1.2.0", "c2"), release("1.1.0", "c0")},
+			opts:     &PlanOptions{Branch: &Branch{Name: "1.1.x", Range: "1.1.x"}},
+			current:  "1.1.0",
+			next:     "1.1.1",
+			nextTag:  "v1.1.1",
+			commits:  1,
+		},
+		{
+			name:     "next prerelease",
+			cfg:      DefaultConfig,
+			versions: versions,
+			opts:     &PlanOptions{Branch: &Branch{Prerelease: "rc"}},
+			current:  "1.2.0",
+			next:     "1.3.0-rc.2",
+			nextTag:  "v1.3.0-rc.2",
+			commits:  2,
+		},
+		{
+			name:     "first prerelease",
+			cfg:      DefaultConfig,
+			versions: versions,
+			opts:     &PlanOptions{Branch: &Branch{Prerelease: "beta"}},
+			current:  "1.2.0",
+			next:     "1.3.0-beta.1",
+			nextTag:  "v1.3.0-beta.1",
+			commits:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pipeline{Config: tt.cfg, Versions: tt.versions, Commits: commits}
+			plan, err := p.Plan(Package{Prefix: "v"}, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Current.String() != tt.current {
+				t.Errorf("expected current %s, got %s", tt.current, plan.Current)
+			}
+			if plan.Next.String() != tt.next {
+				t.Errorf("expected next %s, got %s", tt.next, plan.Next.String())
+			}
+			if plan.NextTag != tt.nextTag {
+				t.Errorf("expected next tag %s, got %s", tt.nextTag, plan.NextTag)
+			}
+			if len(plan.Commits) != tt.commits {
+				t.Errorf("expected %d commits, got %d", tt.commits, len(plan.Commits))
+			}
+		})
+	}
+}
+
+func TestPipelinePlanPrereleaseWithoutCommits(t *testing.T) {
+	p := &Pipeline{
+		Config:   DefaultConfig,
+		Versions: fakeVersions{release("1.3.0-rc.1", "c3"), release("1.2.0", "c2")},
+		Commits:  fakeCommits{"c2": {change("c", "feat: c")}},
+	}
+	plan, err := p.Plan(Package{Prefix: "v"}, &PlanOptions{Branch: &Branch{Prerelease: "rc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Needed() {
+		t.Errorf("expected nothing to release, got %s", plan.Next.String())
+	}
+	if plan.CurrentTag != "v1.3.0-rc.1" {
+		t.Errorf("expected the prerelease to be current, got %s", plan.CurrentTag)
+	}
+}
+
+func TestPipelinePlanFoundTags(t *testing.T) {
+	// the tags found have a v prefix, the configured format has none
+	p := &Pipeline{
+		Config:   DefaultConfig,
+		Versions: fakeVersions{release("1.3.0-rc.1", "c3"), release("1.0.0", "c1")},
+		Commits:  fakeCommits{"c1": {change("a", "fix: a")}},
+	}
+	plan, err := p.Plan(Package{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.CurrentTag != "v1.0.0" || plan.NextTag != "1.0.1" {
+		t.Errorf("expected v1.0.0 and 1.0.1, got %s and %s", plan.CurrentTag, plan.NextTag)
+	}
+
+	p.Versions = fakeVersions{release("1.0.1-rc.1", "c3"), release("1.0.0", "c1")}
+	plan, err = p.Plan(Package{}, &PlanOptions{Branch: &Branch{Prerelease: "rc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Needed() || plan.CurrentTag != "v1.0.1-rc.1" || plan.NextTag != "v1.0.1-rc.1" {
+		t.Errorf("expected the prerelease tag v1.0.1-rc.1, got %s and %s", plan.CurrentTag, plan.NextTag)
+	}
+
+	p.Versions = fakeVersions{}
+	plan, err = p.Plan(Package{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.CurrentTag != "" || plan.NextTag != "1.0.0" {
+		t.Errorf("expected no current tag and 1.0.0, got %q and %q", plan.CurrentTag, plan.NextTag)
+	}
+}
+
+func TestPipelinePublish(t *testing.T) {
+	tagger := &fakeTagger{}
+	releaser := &fakeReleaser{}
+	p := &Pipeline{
+		Config:   DefaultConfig,
+		Versions: fakeVersions{release("1.0.0", "c1")},
+		Commits:  fakeCommits{"c1": {change("a", "fix: a")}},
+		Tagger:   tagger,
+		Releaser: releaser,
+		ReleaseOptions: ReleaseOptions{
+			Assets: []Asset{{Path: "dist/app.tar.gz"}},
+			Draft:  true,
+		},
+		Notes: func(plan *Plan) (string, error) {
+			return "- " + plan.Commits[0].Description, nil
+		},
+	}
+	plan, err := p.Plan(Package{Prefix: "v"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := p.Publish(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://example.com/releases/v1.0.1" {
+		t.Errorf("unexpected release URL %s", url)
+	}
+	if len(tagger.tags) != 1 || tagger.tags[0] != "v1.0.1" {
+		t.Errorf("expected v1.0.1 to be tagged, got %v", tagger.tags)
+	}
+	if releaser.tag != "v1.0.1" || releaser.notes != "- a" || len(releaser.opts.Assets) != 1 || !releaser.opts.Draft {
+		t.Errorf("unexpected release %s with notes %q", releaser.tag, releaser.notes)
+	}
+	if releaser.opts.Prerelease {
+		t.Error("expected a final release")
+	}
+
+	// nothing is published without a version to release
+	p.Commits = fakeCommits{}
+	tagger.tags = nil
+	plan, err = p.Plan(Package{Prefix: "v"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url, err := p.Publish(plan); err != nil || url != "" {
+		t.Errorf("expected nothing published, got %q, %v", url, err)
+	}
+	if len(tagger.tags) != 0 {
+		t.Errorf("expected no tag, got %v", tagger.tags)
+	}
+
+	// prerelease versions are prereleases on the platform
+	p.Commits = fakeCommits{"c1": {change("a", "fix: a")}}
+	plan, err = p.Plan(Package{Prefix: "v"}, &PlanOptions{Branch: &Branch{Prerelease: "rc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Publish(plan); err != nil {
+		t.Fatal(err)
+	}
+	if releaser.tag != "v1.0.1-rc.1" || !releaser.opts.Prerelease {
+		t.Errorf("expected prerelease v1.0.1-rc.1, got %s with %+v", releaser.tag, releaser.opts)
+	}
+
+	releaser.err = errors.New("boom")
+	plan, err = p.Plan(Package{Prefix: "v"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Publish(plan); !errors.Is(err, releaser.err) {
+		t.Errorf("expected the release error, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package semrel
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// DefaultTagTemplate renders the version after the prefix, e.g. "v1.2.3"
+const DefaultTagTemplate = "{{ .Prefix }}{{ .Version }}"
+
+// versionPlaceholder stands in for the version when deriving a tag pattern
+// from a template
+const versionPlaceholder = "\x00"
+
+var ErrNoVersionGroup = errors.New("tag pattern has no \"version\" group")
+
+// TagData is passed to tag templates
+type TagData struct {
+	Prefix  string
+	Package string
+	Version string
+}
+
+// TagFormat converts between versions and tag names of a package
+type TagFormat struct {
+	tmpl    *template.Template
+	pattern *regexp.Regexp
+	prefix  string
+	pkg     string
+}
+
+// NewTagFormat creates a TagFormat for pkg. If pattern is nil, it is derived
+// from tmpl by matching everything the template renders around the version.
+func NewTagFormat(tmpl *template.Template, pattern *regexp.Regexp, pkg Package) (*TagFormat, error) {
+	f := &TagFormat{
+		tmpl:    tmpl,
+		pattern: pattern,
+		prefix:  pkg.Prefix,
+		pkg:     pkg.Name,
+	}
+	if f.pattern == nil {
+		s, err := f.render(versionPlaceholder)
+		if err != nil {
+			return nil, err
+		}
+		before, after, ok := strings.Cut(s, versionPlaceholder)
+		if !ok {
+			return nil, errors.New("tag template does not render the version")
+		}
+		f.pattern = regexp.MustCompile("^" + regexp.QuoteMeta(before) + "(?P<version>.+)" + regexp.QuoteMeta(after) + "$")
+	}
+	if f.pattern.SubexpIndex("version") < 0 {
+		return nil, ErrNoVersionGroup
+	}
+	return f, nil
+}
+
+// Tag returns the tag name for version v
+func (f *TagFormat) Tag(v *semver.Version) (string, error) {
+	return f.render(v.String())
+}
+
+// Version parses the version from tag. It reports false if the tag does not
+// match the format or does not hold a valid version.
+func (f *TagFormat) Version(tag string) (*semver.Version, bool) {
+	m := f.pattern.FindStringSubmatch(tag)
+	if m == nil {
+		return nil, false
+	}
+	v, err := semver.NewVersion(m[f.pattern.SubexpIndex("version")])
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func (f *TagFormat) render(version string) (string, error) {
+	b := strings.Builder{}
+	err := f.tmpl.Execute(&b, TagData{
+		Prefix:  f.prefix,
+		Package: f.pkg,
+		Version: version,
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
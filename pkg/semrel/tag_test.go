@@ -0,0 +1,142 @@
+package semrel
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestTagFormatPrefix(t *testing.T) {
+	c, err := NewConfig(WithPrefix("api/v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.TagFormat(Package{Prefix: c.Prefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tag, err := f.Tag(semver.New(1, 2, 3, "rc.1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "api/v1.2.3-rc.1" {
+		t.Errorf("expected 'api/v1.2.3-rc.1', got %q", tag)
+	}
+
+	tests := map[string]string{
+		"api/v1.2.3":      "1.2.3",
+		"api/v1.2.3-rc.1": "1.2.3-rc.1",
+		"v1.2.3":          "",
+		"web/v1.2.3":      "",
+		"api/vlatest":     "",
+	}
+	for tag, want := range tests {
+		v, ok := f.Version(tag)
+		if want == "" {
+			if ok {
+				t.Errorf("%s: expected no match, got %s", tag, v)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: expected %s, got no match", tag, want)
+			continue
+		}
+		if v.String() != want {
+			t.Errorf("%s: expected %s, got %s", tag, want, v)
+		}
+	}
+}
+
+func TestTagFormatTemplate(t *testing.T) {
+	c, err := NewConfig(WithTagTemplate("{{ .Package }}@{{ .Version }}-final"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.TagFormat(Package{Name: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := f.Tag(semver.New(0, 4, 0, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "api@0.4.0-final" {
+		t.Errorf("expected 'api@0.4.0-final', got %q", tag)
+	}
+	v, ok := f.Version(tag)
+	if !ok || v.String() != "0.4.0" {
+		t.Errorf("expected 0.4.0, got %v", v)
+	}
+	if _, ok := f.Version("web@0.4.0-final"); ok {
+		t.Error("expected tag of other package not to match")
+	}
+}
+
+func TestTagFormatPattern(t *testing.T) {
+	c, err := NewConfig(
+		WithTagTemplate("release-{{ .Version }}"),
+		WithTagPattern(`^(?:release|rel)-(?P<version>.+)$`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.TagFormat(Package{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"release-1.0.0", "rel-1.0.0"} {
+		v, ok := f.Version(tag)
+		if !ok || v.String() != "1.0.0" {
+			t.Errorf("%s: expected 1.0.0, got %v", tag, v)
+		}
+	}
+}
+
+func TestTagFormatPatternPackages(t *testing.T) {
+	api := Package{Name: "api", Path: "services/api"}
+	web := Package{Name: "web", Path: "services/web"}
+	c, err := NewConfig(
+		WithPackages(api, web),
+		WithTagTemplate("{{ .Package }}@{{ .Version }}"),
+		WithTagPattern(`^{{ .Package }}@v?(?P<version>.+)$`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.TagFormat(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := f.Version("api@1.0.0"); !ok || v.String() != "1.0.0" {
+		t.Errorf("expected 1.0.0, got %v", v)
+	}
+	if _, ok := f.Version("web@3.0.0"); ok {
+		t.Error("expected tag of other package not to match")
+	}
+
+	// a pattern of every package is ambiguous
+	_, err = NewConfig(
+		WithPackages(api, web),
+		WithTagTemplate("{{ .Package }}@{{ .Version }}"),
+		WithTagPattern(`^[a-z]+@(?P<version>.+)$`),
+	)
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestTagFormatInvalid(t *testing.T) {
+	for _, opts := range [][]ConfigOption{
+		{WithTagTemplate("{{ .Prefix }}")},
+		{WithTagTemplate("{{ .Version ")},
+		{WithTagPattern(`^v(.+)$`)},
+		{WithTagPattern(`^v(?P<version>.+$`)},
+		{WithTagPattern(`^{{ .Name }}(?P<version>.+)$`)},
+	} {
+		if _, err := NewConfig(opts...); err == nil {
+			t.Error("expected error, got nil")
+		}
+	}
+}
@@ -1,34 +1,93 @@
 package semrel
 
-import "github.com/Masterminds/semver/v3"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Decision explains how the next version was computed
+type Decision struct {
+	// Bump is the highest bump of the commits
+	Bump BumpKind
+	// Default is true if no commit bumped and the default bump was applied
+	Default bool
+	// Development is true if a major bump was downgraded to a patch bump,
+	// because the current version is still in development
+	Development bool
+	// Applied is the bump applied to the current version
+	Applied BumpKind
+	// Next is the next version
+	Next semver.Version
+}
 
 func NextVersion(current *semver.Version, commits []*Commit, cfg *Config) semver.Version {
-	currentBump := BumpNone
+	return Decide(current, commits, cfg).Next
+}
+
+// Decide computes the next version from the commits since current, recording
+// how it got there.
+func Decide(current *semver.Version, commits []*Commit, cfg *Config) *Decision {
+	d := &Decision{}
 	for _, c := range commits {
 		b := c.BumpKind(cfg)
-		if b.IsGreater(currentBump) {
-			currentBump = b
+		if b.IsGreater(d.Bump) {
+			d.Bump = b
 		}
-		if currentBump == BumpMajor {
+		if d.Bump == BumpMajor {
 			break
 		}
 	}
-	if currentBump == BumpNone {
-		currentBump = cfg.DefaultBump()
+	d.Applied = d.Bump
+	if d.Applied == BumpNone {
+		d.Applied = cfg.DefaultBump()
+		d.Default = d.Applied != BumpNone
+	}
+	switch d.Applied {
+	case BumpMajor:
+		if cfg.IsDevelopment() && current.Major() == 0 {
+			d.Development = true
+			d.Applied = BumpPatch
+			d.Next = current.IncPatch()
+		} else {
+			d.Next = current.IncMajor()
+		}
+	case BumpMinor:
+		d.Next = current.IncMinor()
+	case BumpPatch:
+		d.Next = current.IncPatch()
+	default:
+		d.Next = *current
 	}
-	if currentBump == BumpMajor {
-		if cfg.IsDevelopment() {
-			if current.Major() == 0 {
-				return current.IncPatch()
-			}
+	return d
+}
+
+// NextPrerelease returns the next numbered prerelease of next in channel,
+// e.g. 1.3.0-rc.3 if 1.3.0-rc.2 is the highest of versions
+func NextPrerelease(next *semver.Version, channel string, versions []*semver.Version) (semver.Version, error) {
+	counter := 0
+	for _, v := range versions {
+		if n, ok := PrereleaseCounter(v, next, channel); ok && n > counter {
+			counter = n
 		}
-		return current.IncMajor()
 	}
-	if currentBump == BumpMinor {
-		return current.IncMinor()
+	return next.SetPrerelease(channel + "." + strconv.Itoa(counter+1))
+}
+
+// PrereleaseCounter returns the counter of v, if v is a numbered prerelease of
+// next in channel
+func PrereleaseCounter(v, next *semver.Version, channel string) (int, bool) {
+	if v.Major() != next.Major() || v.Minor() != next.Minor() || v.Patch() != next.Patch() {
+		return 0, false
+	}
+	counter, ok := strings.CutPrefix(v.Prerelease(), channel+".")
+	if !ok {
+		return 0, false
 	}
-	if currentBump == BumpPatch {
-		return current.IncPatch()
+	n, err := strconv.Atoi(counter)
+	if err != nil {
+		return 0, false
 	}
-	return *current
+	return n, true
 }
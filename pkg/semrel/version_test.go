@@ -0,0 +1,104 @@
+package semrel
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestDecide(t *testing.T) {
+	dev, err := NewConfig(WithDevelopment(), WithMinorTypes("feat"), WithPatchTypes("fix"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, err := NewConfig(WithDefaultBump(BumpPatch), WithMinorTypes("feat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		current *semver.Version
+		commits []*Commit
+		want    Decision
+	}{
+		{
+			name:    "highest",
+			cfg:     DefaultConfig,
+			current: semver.New(1, 2, 3, "", ""),
+			commits: []*Commit{{Type: "fix"}, {Type: "feat"}, {Type: "docs"}},
+			want:    Decision{Bump: BumpMinor, Applied: BumpMinor, Next: *semver.New(1, 3, 0, "", "")},
+		},
+		{
+			name:    "none",
+			cfg:     DefaultConfig,
+			current: semver.New(1, 2, 3, "", ""),
+			commits: []*Commit{{Type: "docs"}},
+			want:    Decision{Next: *semver.New(1, 2, 3, "", "")},
+		},
+		{
+			name:    "default",
+			cfg:     def,
+			current: semver.New(1, 2, 3, "", ""),
+			commits: []*Commit{{Type: "docs"}},
+			want:    Decision{Default: true, Applied: BumpPatch, Next: *semver.New(1, 2, 4, "", "")},
+		},
+		{
+			name:    "development",
+			cfg:     dev,
+			current: semver.New(0, 2, 0, "", ""),
+			commits: []*Commit{{Type: "feat", Attention: true}},
+			want:    Decision{Bump: BumpMajor, Development: true, Applied: BumpPatch, Next: *semver.New(0, 2, 1, "", "")},
+		},
+		{
+			name:    "development released",
+			cfg:     dev,
+			current: semver.New(1, 0, 0, "", ""),
+			commits: []*Commit{{Type: "feat", Attention: true}},
+			want:    Decision{Bump: BumpMajor, Applied: BumpMajor, Next: *semver.New(2, 0, 0, "", "")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Decide(tt.current, tt.commits, tt.cfg)
+			if d.Bump != tt.want.Bump || d.Default != tt.want.Default ||
+				d.Development != tt.want.Development || d.Applied != tt.want.Applied ||
+				!d.Next.Equal(&tt.want.Next) {
+				t.Errorf("expected %+v, got %+v", tt.want, *d)
+			}
+			if next := NextVersion(tt.current, tt.commits, tt.cfg); !next.Equal(&d.Next) {
+				t.Errorf("expected NextVersion %s, got %s", d.Next.String(), next.String())
+			}
+		})
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	next := semver.MustParse("1.3.0")
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+	}{
+		{"first", []string{"1.2.0", "1.3.0-beta.4"}, "1.3.0-rc.1"},
+		{"counter", []string{"1.3.0-rc.1", "1.3.0-rc.2", "1.2.0-rc.7"}, "1.3.0-rc.3"},
+		{"unordered", []string{"1.3.0-rc.10", "1.3.0-rc.9"}, "1.3.0-rc.11"},
+		{"not numbered", []string{"1.3.0-rc", "1.3.0-rc.x"}, "1.3.0-rc.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := []*semver.Version{}
+			for _, v := range tt.versions {
+				versions = append(versions, semver.MustParse(v))
+			}
+			got, err := NextPrerelease(next, "rc", versions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got.String())
+			}
+		})
+	}
+}